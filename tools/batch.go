@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sync"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// BatchItem is a single entry in a batch request: one userRules/referenceRules
+// pair to validate, identified by ID so the result can be matched back up by
+// the caller. Konflux CI typically validates dozens of RoleBindings per PR;
+// batch mode lets that happen in one process instead of one fork per pair.
+type BatchItem struct {
+	ID                   string              `json:"id"`
+	UserRules            []rbacv1.PolicyRule `json:"userRules"`
+	ReferenceRules       []rbacv1.PolicyRule `json:"referenceRules"`
+	Explain              bool                `json:"explain,omitempty"`
+	Mode                 string              `json:"mode,omitempty"`
+	RequestedRules       []rbacv1.PolicyRule `json:"requestedRules,omitempty"`
+	AccessRequests       []AccessRequest     `json:"accessRequests,omitempty"`
+	SubresourceWildcards bool                `json:"subresourceWildcards,omitempty"`
+	UserRefs             *ResolveInput       `json:"userRefs,omitempty"`
+	ReferenceRefs        *ResolveInput       `json:"referenceRefs,omitempty"`
+}
+
+// BatchInput is the stdin shape for batch mode: {"items": [...]}. Its
+// presence (as opposed to a bare ValidationInput) is how main decides
+// whether to run in batch mode.
+type BatchInput struct {
+	Items []BatchItem `json:"items"`
+}
+
+// toValidationInput converts a BatchItem into the ValidationInput shape the
+// single-item modes already understand, so batch mode is just a thin
+// fan-out over the same validation logic.
+func (b BatchItem) toValidationInput() ValidationInput {
+	return ValidationInput{
+		UserRules:            b.UserRules,
+		ReferenceRules:       b.ReferenceRules,
+		Explain:              b.Explain,
+		Mode:                 b.Mode,
+		RequestedRules:       b.RequestedRules,
+		AccessRequests:       b.AccessRequests,
+		SubresourceWildcards: b.SubresourceWildcards,
+		UserRefs:             b.UserRefs,
+		ReferenceRefs:        b.ReferenceRefs,
+	}
+}
+
+// sniffBatchInput reports whether raw looks like a BatchInput (a JSON object
+// with an "items" key) as opposed to a bare ValidationInput.
+func sniffBatchInput(raw []byte) (BatchInput, bool) {
+	var probe struct {
+		Items *[]BatchItem `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil || probe.Items == nil {
+		return BatchInput{}, false
+	}
+	return BatchInput{Items: *probe.Items}, true
+}
+
+// parallelFlag controls how many goroutines runBatch fans validation out
+// across. validation.Covers is pure and CPU-bound, so batches parallelize
+// cleanly.
+var parallelFlag = flag.Int("parallel", 1, "number of goroutines to use when validating a batch")
+
+// runBatch validates every item in a batch concurrently across parallelism
+// goroutines and returns the per-item results keyed by ID. Per-item
+// validation never itself errors (ValidationOutput always has a well-formed
+// zero value), so the aggregated error here only ever reports duplicate or
+// missing IDs.
+func runBatch(items []BatchItem, parallelism int) (map[string]ValidationOutput, error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	results := make(map[string]ValidationOutput, len(items))
+	var mu sync.Mutex
+	var errs []error
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		if item.ID == "" {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("item %d: missing required \"id\" field", i))
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(item BatchItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			output := runMode(item.toValidationInput())
+
+			mu.Lock()
+			if _, exists := results[item.ID]; exists {
+				errs = append(errs, fmt.Errorf("duplicate item id %q", item.ID))
+			}
+			results[item.ID] = output
+			mu.Unlock()
+		}(item)
+	}
+
+	wg.Wait()
+
+	return results, utilerrors.NewAggregate(errs)
+}
+
+// anyDenied reports whether any result in a batch failed its check (Covers
+// is the pass/fail field populated by every mode: "covers" success,
+// "escalation" non-escalation, "query" all-requests-allowed), so main can
+// exit non-zero for a batch the same way it would for a single denied item.
+func anyDenied(results map[string]ValidationOutput) bool {
+	for _, output := range results {
+		if !output.Covers {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestSniffBatchInput(t *testing.T) {
+	if _, ok := sniffBatchInput([]byte(`{"userRules":[],"referenceRules":[]}`)); ok {
+		t.Error("expected a bare ValidationInput not to be sniffed as a batch")
+	}
+	if _, ok := sniffBatchInput([]byte(`{"items":[{"id":"a","userRules":[],"referenceRules":[]}]}`)); !ok {
+		t.Error("expected an {items: [...]} payload to be sniffed as a batch")
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	items := []BatchItem{
+		{
+			ID: "allowed",
+			UserRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+			},
+			ReferenceRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+			},
+		},
+		{
+			ID: "denied",
+			UserRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+			},
+			ReferenceRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+			},
+		},
+	}
+
+	results, err := runBatch(items, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if !results["allowed"].Covers {
+		t.Error("expected \"allowed\" item to be covered")
+	}
+	if results["denied"].Covers {
+		t.Error("expected \"denied\" item not to be covered")
+	}
+}
+
+func TestRunBatchMissingID(t *testing.T) {
+	items := []BatchItem{
+		{UserRules: []rbacv1.PolicyRule{}, ReferenceRules: []rbacv1.PolicyRule{}},
+	}
+
+	if _, err := runBatch(items, 1); err == nil {
+		t.Error("expected an error for an item missing an id")
+	}
+}
+
+func TestBatchItemToValidationInputThreadsRefsAndWildcards(t *testing.T) {
+	userRefs := &ResolveInput{Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"}}
+	referenceRefs := &ResolveInput{Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "bob"}}
+
+	item := BatchItem{
+		ID:                   "a",
+		SubresourceWildcards: true,
+		UserRefs:             userRefs,
+		ReferenceRefs:        referenceRefs,
+	}
+
+	input := item.toValidationInput()
+	if !input.SubresourceWildcards {
+		t.Error("expected SubresourceWildcards to carry through to ValidationInput")
+	}
+	if input.UserRefs != userRefs {
+		t.Error("expected UserRefs to carry through to ValidationInput")
+	}
+	if input.ReferenceRefs != referenceRefs {
+		t.Error("expected ReferenceRefs to carry through to ValidationInput")
+	}
+}
+
+func TestAnyDenied(t *testing.T) {
+	allAllowed := map[string]ValidationOutput{
+		"a": {Covers: true},
+		"b": {Covers: true},
+	}
+	if anyDenied(allAllowed) {
+		t.Error("expected anyDenied = false when every item covers")
+	}
+
+	oneDenied := map[string]ValidationOutput{
+		"a": {Covers: true},
+		"b": {Covers: false},
+	}
+	if !anyDenied(oneDenied) {
+		t.Error("expected anyDenied = true when one item doesn't cover")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// CompactRules merges PolicyRules that share the same (APIGroups, Resources,
+// ResourceNames, NonResourceURLs) tuple, unioning their Verbs, then drops
+// any resulting rule that is fully subsumed by a wildcard sibling, and
+// finally returns the result sorted deterministically. This mirrors
+// OpenShift's rulevalidation.CompactRules + SortableRuleSlice: it
+// normalizes verbose, overlapping roles (common in infra-deployments) into
+// a canonical form before comparison, so a semantically-equivalent but
+// differently-written role compares equal. Grouping must key on Resources,
+// not Verbs: merging rules that only share a verb set but cover different
+// resources would grant combinations (e.g. "list configmaps") that were
+// never actually present in the original rules.
+func CompactRules(rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	type key string
+	groups := map[key]*rbacv1.PolicyRule{}
+	var order []key
+
+	for _, rule := range rules {
+		k := key(ruleGroupKey(rule))
+		existing, ok := groups[k]
+		if !ok {
+			merged := rule.DeepCopy()
+			merged.Verbs = dedupSorted(rule.Verbs)
+			merged.Resources = dedupSorted(rule.Resources)
+			groups[k] = merged
+			order = append(order, k)
+			continue
+		}
+		existing.Verbs = dedupSorted(append(existing.Verbs, rule.Verbs...))
+	}
+
+	compacted := make([]rbacv1.PolicyRule, 0, len(order))
+	for _, k := range order {
+		compacted = append(compacted, *groups[k])
+	}
+
+	compacted = dropSubsumedRules(compacted)
+	sortRules(compacted)
+	return compacted
+}
+
+// ruleGroupKey returns the grouping key CompactRules merges rules on: the
+// sorted APIGroups, Resources, ResourceNames and NonResourceURLs. Rules
+// only differing in Verbs collapse into a single compacted rule.
+func ruleGroupKey(rule rbacv1.PolicyRule) string {
+	return strings.Join([]string{
+		strings.Join(dedupSorted(rule.APIGroups), ","),
+		strings.Join(dedupSorted(rule.Resources), ","),
+		strings.Join(dedupSorted(rule.ResourceNames), ","),
+		strings.Join(dedupSorted(rule.NonResourceURLs), ","),
+	}, "|")
+}
+
+// dropSubsumedRules removes any rule whose permissions are entirely implied
+// by a fully wildcarded sibling rule (APIGroups=["*"], Verbs=["*"],
+// Resources=["*"], with no ResourceNames/NonResourceURLs constraint).
+func dropSubsumedRules(rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	hasFullWildcard := false
+	for _, rule := range rules {
+		if isFullWildcard(rule) {
+			hasFullWildcard = true
+			break
+		}
+	}
+	if !hasFullWildcard {
+		return rules
+	}
+
+	var kept []rbacv1.PolicyRule
+	for _, rule := range rules {
+		if isFullWildcard(rule) || len(rule.ResourceNames) > 0 || len(rule.NonResourceURLs) > 0 {
+			kept = append(kept, rule)
+		}
+	}
+	return kept
+}
+
+func isFullWildcard(rule rbacv1.PolicyRule) bool {
+	return len(rule.ResourceNames) == 0 && len(rule.NonResourceURLs) == 0 &&
+		containsOnly(rule.APIGroups, rbacv1.APIGroupAll) &&
+		containsOnly(rule.Resources, rbacv1.ResourceAll) &&
+		containsOnly(rule.Verbs, rbacv1.VerbAll)
+}
+
+func containsOnly(values []string, want string) bool {
+	return len(values) == 1 && values[0] == want
+}
+
+// dedupSorted returns values deduplicated and sorted, for stable comparison
+// and output.
+func dedupSorted(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// sortRules orders compacted rules deterministically so the same logical
+// role always serializes identically, regardless of input order.
+func sortRules(rules []rbacv1.PolicyRule) {
+	sort.Slice(rules, func(i, j int) bool {
+		return ruleSortKey(rules[i]) < ruleSortKey(rules[j])
+	})
+}
+
+func ruleSortKey(rule rbacv1.PolicyRule) string {
+	return strings.Join([]string{
+		strings.Join(rule.APIGroups, ","),
+		strings.Join(rule.Resources, ","),
+		strings.Join(rule.ResourceNames, ","),
+		strings.Join(rule.NonResourceURLs, ","),
+		strings.Join(rule.Verbs, ","),
+	}, "|")
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestCompactRulesMergesSameGroup(t *testing.T) {
+	rules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list"}},
+		{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+	}
+
+	compacted := CompactRules(rules)
+	if len(compacted) != 2 {
+		t.Fatalf("expected 2 compacted rules, got %d: %+v", len(compacted), compacted)
+	}
+
+	var podsRule *rbacv1.PolicyRule
+	for i := range compacted {
+		if compacted[i].Resources[0] == "pods" {
+			podsRule = &compacted[i]
+		}
+	}
+	if podsRule == nil {
+		t.Fatal("expected a compacted rule for pods")
+	}
+	if len(podsRule.Verbs) != 2 {
+		t.Errorf("expected pods rule verbs to be merged into [get, list], got %v", podsRule.Verbs)
+	}
+}
+
+func TestCompactRulesDropsSubsumedByWildcard(t *testing.T) {
+	rules := []rbacv1.PolicyRule{
+		{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+	}
+
+	compacted := CompactRules(rules)
+	if len(compacted) != 1 {
+		t.Fatalf("expected the pods rule to be dropped as subsumed by the wildcard, got %+v", compacted)
+	}
+	if !isFullWildcard(compacted[0]) {
+		t.Errorf("expected the remaining rule to be the full wildcard, got %+v", compacted[0])
+	}
+}
+
+func TestCompactRulesIsDeterministic(t *testing.T) {
+	a := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get"}},
+	}
+	b := []rbacv1.PolicyRule{
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list"}},
+	}
+
+	ca := CompactRules(a)
+	cb := CompactRules(b)
+	if ruleSortKey(ca[0]) != ruleSortKey(cb[0]) || ruleSortKey(ca[1]) != ruleSortKey(cb[1]) {
+		t.Errorf("expected CompactRules to order output deterministically regardless of input order: %+v vs %+v", ca, cb)
+	}
+}
+
+func TestVerboseRoleComparesEqualToCompactReference(t *testing.T) {
+	verboseUser := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"watch"}},
+	}
+	compactReference := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list", "watch"}},
+	}
+
+	output := runCoversMode(ValidationInput{UserRules: verboseUser, ReferenceRules: compactReference})
+	if !output.Covers {
+		t.Errorf("expected a verbose but equivalent user role to be covered, got diagnostics %v", output.Diagnostics)
+	}
+}
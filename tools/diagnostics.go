@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// String renders a RuleExplanation as the human-readable diagnostic line CI
+// failure messages can show directly to a PR author, e.g.:
+//
+//	verb "delete" on resource "secrets" in group "" not granted by any reference rule
+//	verb "get" on nonResourceURL "/metrics" not granted by any reference rule
+func (e RuleExplanation) String() string {
+	if e.NonResourceURL != "" {
+		return fmt.Sprintf("verb %q on nonResourceURL %q not granted by any reference rule", e.Verb, e.NonResourceURL)
+	}
+	if e.ResourceName != "" {
+		return fmt.Sprintf("verb %q on resource %q (name %q) in group %q not granted by any reference rule",
+			e.Verb, e.Resource, e.ResourceName, e.APIGroup)
+	}
+	return fmt.Sprintf("verb %q on resource %q in group %q not granted by any reference rule", e.Verb, e.Resource, e.APIGroup)
+}
+
+// diagnoseUncoveredRules turns the raw uncovered PolicyRule set into a flat
+// list of human-readable diagnostic lines, one per atomic (verb, apiGroup,
+// resource, resourceName, nonResourceURL) tuple. It is always computed for
+// a denied result, independent of ValidationInput.Explain, since CI failure
+// messages need this regardless of whether the caller also wants the
+// structured breakdown.
+func diagnoseUncoveredRules(rules []rbacv1.PolicyRule) []string {
+	var diagnostics []string
+	for _, rule := range rules {
+		for _, explanation := range explainRule(rule) {
+			diagnostics = append(diagnostics, explanation.String())
+		}
+	}
+	return diagnostics
+}
+
+// RuleDiagnosis is the --explain per-rule reasoning for one uncovered rule:
+// which reference rule (if any) came closest to granting it, and the
+// ReasonXxx code (see reasons.go) for what portion still went uncovered.
+type RuleDiagnosis struct {
+	Rule                 rbacv1.PolicyRule  `json:"rule"`
+	ClosestReferenceRule *rbacv1.PolicyRule `json:"closestReferenceRule,omitempty"`
+	Reason               string             `json:"reason"`
+}
+
+// explainUncoveredRules pairs each uncovered rule with the reference rule
+// that matched it furthest (apiGroup, then resource, then verb) before
+// falling short, using the same cascading logic as classifyUncoveredRule.
+func explainUncoveredRules(uncovered, referenceRules []rbacv1.PolicyRule) []RuleDiagnosis {
+	diagnoses := make([]RuleDiagnosis, 0, len(uncovered))
+	for _, rule := range uncovered {
+		diagnoses = append(diagnoses, RuleDiagnosis{
+			Rule:                 rule,
+			ClosestReferenceRule: closestReferenceRule(rule, referenceRules),
+			Reason:               classifyUncoveredRule(rule, referenceRules),
+		})
+	}
+	return diagnoses
+}
+
+// closestReferenceRule returns the reference rule that matched the most of
+// rule's (apiGroup, resource, verb) before falling short, or nil if no
+// reference rule matched any part of it (or rule is a nonResourceURL rule,
+// which has no meaningful "closest" resource rule).
+func closestReferenceRule(rule rbacv1.PolicyRule, referenceRules []rbacv1.PolicyRule) *rbacv1.PolicyRule {
+	if len(rule.NonResourceURLs) > 0 {
+		return nil
+	}
+
+	groups := rule.APIGroups
+	if len(groups) == 0 {
+		groups = []string{""}
+	}
+	resources := rule.Resources
+	if len(resources) == 0 {
+		resources = []string{""}
+	}
+
+	var best *rbacv1.PolicyRule
+	bestScore := 0
+	for i, refRule := range referenceRules {
+		if len(refRule.NonResourceURLs) > 0 || !anyGrants(refRule.APIGroups, groups) {
+			continue
+		}
+		score := 1
+		if anyGrants(refRule.Resources, resources) {
+			score++
+			if anyGrants(refRule.Verbs, rule.Verbs) {
+				score++
+			}
+		}
+		if score > bestScore {
+			bestScore = score
+			best = &referenceRules[i]
+		}
+	}
+	return best
+}
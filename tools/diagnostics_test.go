@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestRuleDiagnosesOnExplain(t *testing.T) {
+	output := runCoversMode(ValidationInput{
+		Explain: true,
+		UserRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"delete"}},
+		},
+		ReferenceRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list"}},
+		},
+	})
+
+	if output.Covers {
+		t.Fatal("expected covers=false")
+	}
+	if len(output.RuleDiagnoses) != 1 {
+		t.Fatalf("expected 1 rule diagnosis, got %d", len(output.RuleDiagnoses))
+	}
+
+	diagnosis := output.RuleDiagnoses[0]
+	if diagnosis.Reason != ReasonExtraVerb {
+		t.Errorf("Reason = %q, want %q", diagnosis.Reason, ReasonExtraVerb)
+	}
+	if diagnosis.ClosestReferenceRule == nil || diagnosis.ClosestReferenceRule.Resources[0] != "secrets" {
+		t.Errorf("expected the secrets reference rule to be named as closest, got %+v", diagnosis.ClosestReferenceRule)
+	}
+}
+
+func TestRuleDiagnosesNotPopulatedWithoutExplain(t *testing.T) {
+	output := runCoversMode(ValidationInput{
+		UserRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"delete"}},
+		},
+		ReferenceRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list"}},
+		},
+	})
+
+	if len(output.RuleDiagnoses) != 0 {
+		t.Errorf("expected no rule diagnoses without Explain set, got %v", output.RuleDiagnoses)
+	}
+}
+
+func TestClosestReferenceRuleNilWhenNoAPIGroupMatches(t *testing.T) {
+	uncovered := rbacv1.PolicyRule{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"networkpolicies"}, Verbs: []string{"get"}}
+	referenceRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+	}
+
+	if got := closestReferenceRule(uncovered, referenceRules); got != nil {
+		t.Errorf("expected no closest reference rule, got %+v", got)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/component-helpers/auth/rbac/validation"
+)
+
+// runEscalationMode mirrors the check kube-apiserver applies via
+// rbac/validation.ConfirmNoEscalation when a non-cluster-admin creates or
+// edits a Role/ClusterRole: a user may only grant rules they themselves
+// already hold. Here UserRules is the requester's effective rule set and
+// RequestedRules is what they are proposing to grant in a Role/ClusterRole
+// diff. If UserRefs is set instead, the requester's effective rule set is
+// resolved from it first, the same way runCoversMode does. Escalation is
+// true, and EscalatingRules lists exactly which requested rules are not
+// already held, whenever RequestedRules is not covered by UserRules.
+func runEscalationMode(input ValidationInput) ValidationOutput {
+	userRules := input.UserRules
+
+	var resolvedUser []rbacv1.PolicyRule
+	if input.UserRefs != nil {
+		resolvedUser = resolveEffectiveRules(*input.UserRefs)
+		userRules = resolvedUser
+	}
+
+	covers, uncovered := validation.Covers(userRules, input.RequestedRules)
+
+	return ValidationOutput{
+		Covers:            covers,
+		Escalation:        !covers,
+		EscalatingRules:   uncovered,
+		ResolvedUserRules: resolvedUser,
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunEscalationMode(t *testing.T) {
+	tests := []struct {
+		name               string
+		userRules          []rbacv1.PolicyRule
+		requestedRules     []rbacv1.PolicyRule
+		expectedEscalation bool
+	}{
+		{
+			name: "requesting a subset of held rules is not an escalation",
+			userRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"*"}, Verbs: []string{"*"}},
+			},
+			requestedRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+			},
+			expectedEscalation: false,
+		},
+		{
+			name: "requesting more than held is an escalation",
+			userRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+			},
+			requestedRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"delete"}},
+			},
+			expectedEscalation: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := ValidationInput{
+				Mode:           "escalation",
+				UserRules:      tt.userRules,
+				RequestedRules: tt.requestedRules,
+			}
+			output := runEscalationMode(input)
+			if output.Escalation != tt.expectedEscalation {
+				t.Errorf("Escalation = %v, want %v", output.Escalation, tt.expectedEscalation)
+			}
+			if tt.expectedEscalation && len(output.EscalatingRules) == 0 {
+				t.Error("expected EscalatingRules to be populated when Escalation is true")
+			}
+		})
+	}
+}
+
+func TestRunEscalationModeResolvesUserRefs(t *testing.T) {
+	refs := ResolveInput{
+		Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"},
+		ClusterRoles: []rbacv1.ClusterRole{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod-reader"},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+				},
+			},
+		},
+		ClusterRoleBindings: []rbacv1.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "alice-pod-reader"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "alice"}},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "pod-reader"},
+			},
+		},
+	}
+
+	output := runEscalationMode(ValidationInput{
+		Mode:     "escalation",
+		UserRefs: &refs,
+		RequestedRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"delete"}},
+		},
+	})
+	if !output.Escalation {
+		t.Error("expected requesting secrets/delete to be an escalation beyond the resolved pod-reader rules")
+	}
+
+	output = runEscalationMode(ValidationInput{
+		Mode:     "escalation",
+		UserRefs: &refs,
+		RequestedRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+		},
+	})
+	if output.Escalation {
+		t.Error("expected requesting an already-held rule resolved from UserRefs not to be an escalation")
+	}
+}
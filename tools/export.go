@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// exportOptions bundles the flags runExportCLI needs: where to load the
+// reference policy from (same as file/walk mode), and where to write each
+// requested export format.
+type exportOptions struct {
+	referenceFile       string
+	referencePolicyDir  string
+	referencePolicyName string
+
+	regoOut     string
+	regoPackage string
+
+	kyvernoOut  string
+	kyvernoName string
+}
+
+// runExportCLI is the -export-rego/-export-kyverno entry point from main:
+// it loads the reference policy the same way file/walk mode do, renders
+// whichever export formats were requested, and writes each to its output
+// path.
+func runExportCLI(opts exportOptions) {
+	referenceRules, err := loadReferenceRulesFromFlags(opts.referenceFile, opts.referencePolicyDir, opts.referencePolicyName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading reference policy: %v\n", err)
+		os.Exit(1)
+	}
+	compactedReference := CompactRules(referenceRules)
+
+	if opts.regoOut != "" {
+		rego := RenderRegoPolicy(compactedReference, opts.regoPackage)
+		if err := os.WriteFile(opts.regoOut, []byte(rego), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing -export-rego: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if opts.kyvernoOut != "" {
+		kyverno, err := RenderKyvernoClusterPolicy(compactedReference, opts.kyvernoName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering -export-kyverno: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(opts.kyvernoOut, kyverno, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing -export-kyverno: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// RenderRegoPolicy translates referenceRules into a self-contained Rego
+// module usable with conftest/OPA: the reference rule set is embedded as
+// static data, and `allow` answers the same SubjectAccessReview-style
+// question query mode does - given {verb, apiGroup, resource, subresource,
+// resourceName, nonResourceURL} input, does any reference rule grant it -
+// using the same wildcard/ResourceAll/VerbAll semantics as ruleAllows in
+// query.go, so a cluster can enforce the identical policy admission-time
+// without running this binary.
+func RenderRegoPolicy(referenceRules []rbacv1.PolicyRule, packageName string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "package %s\n\n", packageName)
+	b.WriteString("default allow = false\n\n")
+
+	b.WriteString("reference_rules = [\n")
+	for _, rule := range referenceRules {
+		fmt.Fprintf(&b, "\t%s,\n", regoRuleObject(rule))
+	}
+	b.WriteString("]\n\n")
+
+	b.WriteString(regoMatchHelpers)
+
+	b.WriteString("\nallow {\n\tsome i\n\trule := reference_rules[i]\n\trule_allows(rule)\n}\n")
+
+	return b.String()
+}
+
+func regoRuleObject(rule rbacv1.PolicyRule) string {
+	return fmt.Sprintf(
+		`{"apiGroups": %s, "resources": %s, "resourceNames": %s, "nonResourceURLs": %s, "verbs": %s}`,
+		regoStringArray(rule.APIGroups),
+		regoStringArray(rule.Resources),
+		regoStringArray(rule.ResourceNames),
+		regoStringArray(rule.NonResourceURLs),
+		regoStringArray(rule.Verbs),
+	)
+}
+
+func regoStringArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// regoMatchHelpers mirrors query.go's ruleAllows/verbMatches/apiGroupMatches/
+// resourceMatches/resourceNameMatches/nonResourceURLMatches rule-by-rule, so
+// the exported policy and this binary agree on every
+// wildcard/ResourceAll/VerbAll/subresource case.
+const regoMatchHelpers = `rule_allows(rule) {
+	input.nonResourceURL != ""
+	verb_matches(rule.verbs)
+	nonresourceurl_matches(rule.nonResourceURLs)
+}
+
+rule_allows(rule) {
+	input.nonResourceURL == ""
+	verb_matches(rule.verbs)
+	apigroup_matches(rule.apiGroups)
+	resource_matches(rule.resources)
+	resourcename_matches(rule.resourceNames)
+}
+
+verb_matches(verbs) {
+	verbs[_] == "*"
+}
+
+verb_matches(verbs) {
+	verbs[_] == input.verb
+}
+
+apigroup_matches(groups) {
+	groups[_] == "*"
+}
+
+apigroup_matches(groups) {
+	groups[_] == input.apiGroup
+}
+
+resource_combined = combined {
+	input.subresource != ""
+	combined := sprintf("%s/%s", [input.resource, input.subresource])
+}
+
+resource_combined = input.resource {
+	input.subresource == ""
+}
+
+resource_matches(resources) {
+	resources[_] == "*"
+}
+
+resource_matches(resources) {
+	resources[_] == resource_combined
+}
+
+resource_matches(resources) {
+	input.subresource != ""
+	resources[_] == sprintf("*/%s", [input.subresource])
+}
+
+resourcename_matches(names) {
+	count(names) == 0
+}
+
+resourcename_matches(names) {
+	names[_] == input.resourceName
+}
+
+nonresourceurl_matches(urls) {
+	urls[_] == input.nonResourceURL
+}
+
+nonresourceurl_matches(urls) {
+	some i
+	url := urls[i]
+	endswith(url, "*")
+	startswith(input.nonResourceURL, trim_suffix(url, "*"))
+}
+`
+
+// RenderKyvernoClusterPolicy translates referenceRules into a Kyverno
+// ClusterPolicy that denies any Role/ClusterRole whose rules grant a verb,
+// apiGroup or resource never granted by referenceRules. Because Kyverno's
+// deny-conditions operators (AnyNotIn) compare literal values rather than
+// re-implementing validation.Covers' per-rule wildcard resolution, this is
+// a coarser check than the Go/Rego implementations: it flattens the
+// reference rules' allowed verbs/apiGroups/resources into one set each, so
+// it can both over-approve (a verb individually allowed somewhere, but
+// never together with the requested apiGroup/resource) and, if any
+// reference rule uses ResourceAll/VerbAll, effectively allow everything on
+// that axis. It is meant as a fast admission-time backstop alongside the
+// authoritative Go/Rego checks, not a full replacement for them.
+func RenderKyvernoClusterPolicy(referenceRules []rbacv1.PolicyRule, policyName string) ([]byte, error) {
+	allowedVerbs := flattenRuleField(referenceRules, func(r rbacv1.PolicyRule) []string { return r.Verbs })
+	allowedGroups := flattenRuleField(referenceRules, func(r rbacv1.PolicyRule) []string { return r.APIGroups })
+	allowedResources := flattenRuleField(referenceRules, func(r rbacv1.PolicyRule) []string { return r.Resources })
+
+	policy := kyvernoClusterPolicy{
+		APIVersion: "kyverno.io/v1",
+		Kind:       "ClusterPolicy",
+		Metadata:   kyvernoMetadata{Name: policyName},
+		Spec: kyvernoClusterPolicySpec{
+			ValidationFailureAction: "Enforce",
+			Background:              false,
+			Rules: []kyvernoRule{
+				{
+					Name: "deny-excess-rbac-rules",
+					Match: kyvernoMatch{Any: []kyvernoResourceFilter{
+						{Resources: kyvernoResourceDescription{Kinds: []string{"Role", "ClusterRole"}}},
+					}},
+					Validate: kyvernoValidate{
+						Message: "rule {{ element }} grants a verb, apiGroup or resource outside the reference policy",
+						ForEach: []kyvernoForEach{
+							{
+								List: "request.object.rules",
+								Deny: &kyvernoDeny{Conditions: kyvernoConditions{Any: []kyvernoCondition{
+									{Key: "{{ element.verbs }}", Operator: "AnyNotIn", Value: allowedVerbs},
+									{Key: "{{ element.apiGroups }}", Operator: "AnyNotIn", Value: allowedGroups},
+									{Key: "{{ element.resources }}", Operator: "AnyNotIn", Value: allowedResources},
+								}}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return yaml.Marshal(policy)
+}
+
+// flattenRuleField collects the deduplicated union of field(rule) across
+// rules, in first-seen order.
+func flattenRuleField(rules []rbacv1.PolicyRule, field func(rbacv1.PolicyRule) []string) []string {
+	seen := make(map[string]struct{})
+	var out []string
+	for _, rule := range rules {
+		for _, value := range field(rule) {
+			if _, ok := seen[value]; ok {
+				continue
+			}
+			seen[value] = struct{}{}
+			out = append(out, value)
+		}
+	}
+	return out
+}
+
+// kyvernoClusterPolicy and friends are the minimal subset of the Kyverno
+// ClusterPolicy CRD shape this generator needs; the full CRD isn't vendored
+// since this binary never submits the policy itself, only renders it.
+type kyvernoClusterPolicy struct {
+	APIVersion string                   `json:"apiVersion"`
+	Kind       string                   `json:"kind"`
+	Metadata   kyvernoMetadata          `json:"metadata"`
+	Spec       kyvernoClusterPolicySpec `json:"spec"`
+}
+
+type kyvernoMetadata struct {
+	Name string `json:"name"`
+}
+
+type kyvernoClusterPolicySpec struct {
+	ValidationFailureAction string        `json:"validationFailureAction"`
+	Background              bool          `json:"background"`
+	Rules                   []kyvernoRule `json:"rules"`
+}
+
+type kyvernoRule struct {
+	Name     string          `json:"name"`
+	Match    kyvernoMatch    `json:"match"`
+	Validate kyvernoValidate `json:"validate"`
+}
+
+type kyvernoMatch struct {
+	Any []kyvernoResourceFilter `json:"any"`
+}
+
+type kyvernoResourceFilter struct {
+	Resources kyvernoResourceDescription `json:"resources"`
+}
+
+type kyvernoResourceDescription struct {
+	Kinds []string `json:"kinds"`
+}
+
+type kyvernoValidate struct {
+	Message string           `json:"message"`
+	ForEach []kyvernoForEach `json:"foreach"`
+}
+
+type kyvernoForEach struct {
+	List string       `json:"list"`
+	Deny *kyvernoDeny `json:"deny,omitempty"`
+}
+
+type kyvernoDeny struct {
+	Conditions kyvernoConditions `json:"conditions"`
+}
+
+type kyvernoConditions struct {
+	Any []kyvernoCondition `json:"any"`
+}
+
+type kyvernoCondition struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Value    []string `json:"value"`
+}
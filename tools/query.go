@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// AccessRequest is a single concrete permission question, shaped like a
+// SelfSubjectAccessReview's ResourceAttributes/NonResourceAttributes: "can
+// this role do verb V on resource R?" rather than requiring the caller to
+// synthesize a one-rule UserRules entry per question.
+type AccessRequest struct {
+	Verb         string `json:"verb"`
+	APIGroup     string `json:"apiGroup,omitempty"`
+	Resource     string `json:"resource,omitempty"`
+	Subresource  string `json:"subresource,omitempty"`
+	ResourceName string `json:"resourceName,omitempty"`
+	// Namespace, when set and ReferenceRefs is also set, restricts matching
+	// to rules granted by a RoleBinding in this namespace (ClusterRoleBinding
+	// grants still apply everywhere, as in the cluster). It has no effect
+	// against a flat ReferenceRules, which carries no binding-namespace
+	// information to filter on.
+	Namespace      string `json:"namespace,omitempty"`
+	NonResourceURL string `json:"nonResourceURL,omitempty"`
+}
+
+// AccessDecision is the answer to one AccessRequest.
+type AccessDecision struct {
+	AccessRequest
+	Allowed bool `json:"allowed"`
+
+	// MatchedRuleIndex is the index into ReferenceRules (or the rules
+	// resolved from ReferenceRefs) of the first rule that granted this
+	// request, mirroring how the kube-apiserver RBAC authorizer short-
+	// circuits on the first matching rule. -1 when Allowed is false.
+	MatchedRuleIndex int `json:"matchedRuleIndex"`
+}
+
+// runQueryMode answers each of input.AccessRequests against
+// input.ReferenceRules (or the rules resolved from input.ReferenceRefs),
+// matching the semantics the kube-apiserver's RBAC authorizer applies when
+// deciding a SelfSubjectAccessReview. When ReferenceRefs is set, a request
+// naming a Namespace is resolved against only the RoleBindings in that
+// namespace (plus cluster-wide ClusterRoleBindings), so a rule granted by a
+// binding in a different namespace can't falsely satisfy it.
+func runQueryMode(input ValidationInput) ValidationOutput {
+	referenceRules := input.ReferenceRules
+	var resolvedReference []rbacv1.PolicyRule
+	if input.ReferenceRefs != nil {
+		resolvedReference = resolveEffectiveRules(*input.ReferenceRefs)
+		referenceRules = resolvedReference
+	}
+
+	decisions := make([]AccessDecision, 0, len(input.AccessRequests))
+	allAllowed := true
+	for _, req := range input.AccessRequests {
+		rules := referenceRules
+		if input.ReferenceRefs != nil && req.Namespace != "" {
+			rules = resolveEffectiveRulesInNamespace(*input.ReferenceRefs, req.Namespace)
+		}
+		index := matchingRuleIndex(rules, req, input.SubresourceWildcards)
+		if index < 0 {
+			allAllowed = false
+		}
+		decisions = append(decisions, AccessDecision{AccessRequest: req, Allowed: index >= 0, MatchedRuleIndex: index})
+	}
+
+	return ValidationOutput{
+		Covers:                 allAllowed,
+		AccessDecisions:        decisions,
+		ResolvedReferenceRules: resolvedReference,
+	}
+}
+
+// matchingRuleIndex returns the index of the first rule in rules that
+// grants req, short-circuiting on the first match the way the
+// kube-apiserver RBAC authorizer does, or -1 if none does.
+// subresourceWildcards mirrors the opt-in SubresourceWildcards covers-mode
+// flag: only with it set does a "pods/*" or "*/scale" reference rule match
+// a concrete subresource request.
+func matchingRuleIndex(rules []rbacv1.PolicyRule, req AccessRequest, subresourceWildcards bool) int {
+	for i, rule := range rules {
+		if ruleAllows(rule, req, subresourceWildcards) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ruleAllows mirrors the kube-apiserver RBAC authorizer's per-rule matching
+// (verb/apiGroup/resource+subresource/resourceName for resource requests,
+// verb/nonResourceURL for non-resource requests).
+func ruleAllows(rule rbacv1.PolicyRule, req AccessRequest, subresourceWildcards bool) bool {
+	if !verbMatches(rule.Verbs, req.Verb) {
+		return false
+	}
+
+	if req.NonResourceURL != "" {
+		return nonResourceURLMatches(rule.NonResourceURLs, req.NonResourceURL)
+	}
+
+	if !apiGroupMatches(rule.APIGroups, req.APIGroup) {
+		return false
+	}
+	if !resourceMatches(rule.Resources, req.Resource, req.Subresource, subresourceWildcards) {
+		return false
+	}
+	return resourceNameMatches(rule.ResourceNames, req.ResourceName)
+}
+
+func verbMatches(verbs []string, requested string) bool {
+	for _, v := range verbs {
+		if v == rbacv1.VerbAll || v == requested {
+			return true
+		}
+	}
+	return false
+}
+
+func apiGroupMatches(groups []string, requested string) bool {
+	for _, g := range groups {
+		if g == rbacv1.APIGroupAll || g == requested {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceMatches mirrors upstream's exact resource matching, plus, when
+// subresourceWildcards is set, the opt-in "pods/*"/"*/scale" subresource
+// wildcard semantics chunk1-5 added to covers mode (see
+// expandSubresourceWildcards).
+func resourceMatches(resources []string, requestedResource, requestedSubresource string, subresourceWildcards bool) bool {
+	combined := requestedResource
+	if requestedSubresource != "" {
+		combined = requestedResource + "/" + requestedSubresource
+	}
+
+	for _, r := range resources {
+		if r == rbacv1.ResourceAll || r == combined {
+			return true
+		}
+		if !subresourceWildcards || requestedSubresource == "" {
+			continue
+		}
+		if isSubresourceWildcard(r) && subresourceWildcardMatches(r, combined) {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceNameMatches(names []string, requested string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, n := range names {
+		if n == requested {
+			return true
+		}
+	}
+	return false
+}
+
+func nonResourceURLMatches(urls []string, requested string) bool {
+	for _, u := range urls {
+		if u == requested {
+			return true
+		}
+		if strings.HasSuffix(u, "*") && strings.HasPrefix(requested, strings.TrimSuffix(u, "*")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunQueryMode(t *testing.T) {
+	referenceRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{"appstudio.redhat.com"}, Resources: []string{"pipelineruns"}, Verbs: []string{"create", "get"}},
+		{APIGroups: []string{""}, Resources: []string{"pods/*"}, Verbs: []string{"get"}},
+	}
+
+	tests := []struct {
+		name        string
+		req         AccessRequest
+		allowed     bool
+		matchedRule int
+	}{
+		{
+			name:        "granted verb and resource",
+			req:         AccessRequest{Verb: "create", APIGroup: "appstudio.redhat.com", Resource: "pipelineruns"},
+			allowed:     true,
+			matchedRule: 0,
+		},
+		{
+			name:        "verb not granted",
+			req:         AccessRequest{Verb: "delete", APIGroup: "appstudio.redhat.com", Resource: "pipelineruns"},
+			allowed:     false,
+			matchedRule: -1,
+		},
+		{
+			name:        "wildcard subresource rule covers a specific subresource with the opt-in flag set",
+			req:         AccessRequest{Verb: "get", APIGroup: "", Resource: "pods", Subresource: "log"},
+			allowed:     true,
+			matchedRule: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runQueryMode(ValidationInput{
+				ReferenceRules:       referenceRules,
+				AccessRequests:       []AccessRequest{tt.req},
+				SubresourceWildcards: true,
+			})
+			if len(output.AccessDecisions) != 1 {
+				t.Fatalf("expected 1 decision, got %d", len(output.AccessDecisions))
+			}
+			decision := output.AccessDecisions[0]
+			if decision.Allowed != tt.allowed {
+				t.Errorf("Allowed = %v, want %v", decision.Allowed, tt.allowed)
+			}
+			if decision.MatchedRuleIndex != tt.matchedRule {
+				t.Errorf("MatchedRuleIndex = %d, want %d", decision.MatchedRuleIndex, tt.matchedRule)
+			}
+		})
+	}
+}
+
+func TestRunQueryModeScopesReferenceRefsToNamespace(t *testing.T) {
+	refs := ResolveInput{
+		Subject: rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "releaser", Namespace: "tenant-a"},
+		Roles: []rbacv1.Role{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "releaser-role", Namespace: "tenant-a"},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+				},
+			},
+		},
+		RoleBindings: []rbacv1.RoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "releaser-binding", Namespace: "tenant-a"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "releaser", Namespace: "tenant-a"}},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "releaser-role"},
+			},
+		},
+	}
+
+	sameNamespace := runQueryMode(ValidationInput{
+		ReferenceRefs:  &refs,
+		AccessRequests: []AccessRequest{{Verb: "get", Resource: "pods", Namespace: "tenant-a"}},
+	})
+	if !sameNamespace.AccessDecisions[0].Allowed {
+		t.Errorf("expected a request naming the binding's own namespace to be allowed")
+	}
+
+	otherNamespace := runQueryMode(ValidationInput{
+		ReferenceRefs:  &refs,
+		AccessRequests: []AccessRequest{{Verb: "get", Resource: "pods", Namespace: "tenant-b"}},
+	})
+	if otherNamespace.AccessDecisions[0].Allowed {
+		t.Errorf("expected a request naming a different namespace than the RoleBinding to be denied, not fall through to the binding's rules")
+	}
+}
@@ -3,7 +3,9 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -14,33 +16,359 @@ import (
 type ValidationInput struct {
 	UserRules      []rbacv1.PolicyRule `json:"userRules"`
 	ReferenceRules []rbacv1.PolicyRule `json:"referenceRules"`
+
+	// Explain, when true, breaks each uncovered rule down into its atomic
+	// (verb, apiGroup, resource, resourceName, nonResourceURL) tuples so
+	// callers can render precise "you asked for X which is not permitted"
+	// messages instead of just the raw uncovered PolicyRule.
+	Explain bool `json:"explain,omitempty"`
+
+	// Mode selects which check the validator performs. "covers" (the
+	// default) checks that ReferenceRules grant everything in UserRules.
+	// "escalation" checks whether RequestedRules would escalate privileges
+	// beyond what UserRules already grants; see runEscalationMode.
+	Mode string `json:"mode,omitempty"`
+
+	// RequestedRules is only used in "escalation" mode: the rules a
+	// Role/ClusterRole diff proposes to grant.
+	RequestedRules []rbacv1.PolicyRule `json:"requestedRules,omitempty"`
+
+	// SubresourceWildcards, when true, additionally honors "*/scale" and
+	// "pods/*" style patterns in ReferenceRules' Resources as matching any
+	// resource's subresource or any subresource of a given resource,
+	// respectively. Upstream validation.Covers treats "*" literally outside
+	// the full-wildcard case, matching the kube-apiserver's RBAC authorizer
+	// before Kubernetes PR #53722; this flag opts into the newer, looser
+	// semantics (also what OpenShift's policy comparator honors) for
+	// reference roles authored against it.
+	SubresourceWildcards bool `json:"subresourceWildcards,omitempty"`
+
+	// UserRefs and ReferenceRefs, when set, replace UserRules/ReferenceRules
+	// respectively with the effective rule set resolved by walking the
+	// given Role/ClusterRole bindings, rather than requiring the caller to
+	// pre-flatten bindings (and get aggregation wrong) themselves.
+	UserRefs      *ResolveInput `json:"userRefs,omitempty"`
+	ReferenceRefs *ResolveInput `json:"referenceRefs,omitempty"`
+
+	// AccessRequests is only used in "query" mode: a list of concrete
+	// permission questions to answer against ReferenceRules, SAR-style.
+	AccessRequests []AccessRequest `json:"accessRequests,omitempty"`
 }
 
 // ValidationOutput represents the output structure for RBAC validation
 type ValidationOutput struct {
 	Covers bool   `json:"covers"`
 	Error  string `json:"error,omitempty"`
+
+	// UncoveredRules is the second return value of validation.Covers: the
+	// rules (or portions of rules) requested by UserRules that are not
+	// granted by ReferenceRules. Empty when Covers is true.
+	UncoveredRules []rbacv1.PolicyRule `json:"uncoveredRules,omitempty"`
+
+	// ExplainedRules is only populated when ValidationInput.Explain is true.
+	// Each entry is a single atomic permission tuple extracted from an
+	// uncovered rule.
+	ExplainedRules []RuleExplanation `json:"explainedRules,omitempty"`
+
+	// Diagnostics holds one human-readable line per atomic uncovered
+	// permission, suitable for printing directly in a CI failure message.
+	// Unlike ExplainedRules, it is always populated (not gated on Explain)
+	// whenever Covers is false.
+	Diagnostics []string `json:"diagnostics,omitempty"`
+
+	// Reasons is a deduplicated set of stable, machine-readable codes (see
+	// reasons.go) classifying why Covers is false, such as EXTRA_APIGROUP or
+	// EXTRA_VERB. Unlike Diagnostics' prose, a batch-validation CI job can
+	// branch on these directly. Populated whenever Covers is false.
+	Reasons []string `json:"reasons,omitempty"`
+
+	// Escalation and EscalatingRules are only populated in "escalation"
+	// mode. Escalation is true if RequestedRules grants anything beyond
+	// what UserRules already holds, and EscalatingRules lists exactly
+	// which requested rules trigger it.
+	Escalation      bool                `json:"escalation,omitempty"`
+	EscalatingRules []rbacv1.PolicyRule `json:"escalatingRules,omitempty"`
+
+	// ResolvedUserRules and ResolvedReferenceRules are only populated when
+	// UserRefs/ReferenceRefs were resolved, showing the effective rule set
+	// the verdict above was actually computed against.
+	ResolvedUserRules      []rbacv1.PolicyRule `json:"resolvedUserRules,omitempty"`
+	ResolvedReferenceRules []rbacv1.PolicyRule `json:"resolvedReferenceRules,omitempty"`
+
+	// AccessDecisions is only populated in "query" mode: one decision per
+	// entry in ValidationInput.AccessRequests, in the same order.
+	AccessDecisions []AccessDecision `json:"accessDecisions,omitempty"`
+
+	// RuleDiagnoses is only populated when Explain is true and Covers is
+	// false: one entry per uncovered rule, naming the reference rule (if
+	// any) that came closest to granting it and why it still fell short of
+	// covering it, similar to the per-rule reasoning ConfirmNoEscalation
+	// gives when it rejects a privilege-escalating diff.
+	RuleDiagnoses []RuleDiagnosis `json:"ruleDiagnoses,omitempty"`
+
+	// CompactedUser and CompactedReference are the canonicalized forms of
+	// UserRules/ReferenceRules (see CompactRules) that the covers check was
+	// actually run against, so reviewers can see the normalized shape of a
+	// verbose role.
+	CompactedUser      []rbacv1.PolicyRule `json:"compactedUser,omitempty"`
+	CompactedReference []rbacv1.PolicyRule `json:"compactedReference,omitempty"`
+}
+
+// RuleExplanation describes a single atomic permission that was requested in
+// UserRules but not granted by ReferenceRules. Unlike a PolicyRule, which can
+// bundle many verbs/resources/groups together, a RuleExplanation names one
+// concrete (verb, apiGroup, resource, resourceName, nonResourceURL) tuple so
+// it can be rendered directly in a denial message.
+type RuleExplanation struct {
+	Verb           string `json:"verb,omitempty"`
+	APIGroup       string `json:"apiGroup,omitempty"`
+	Resource       string `json:"resource,omitempty"`
+	ResourceName   string `json:"resourceName,omitempty"`
+	NonResourceURL string `json:"nonResourceURL,omitempty"`
+}
+
+// explainRule decomposes a single uncovered PolicyRule into atomic
+// RuleExplanation tuples by taking the cartesian product of its verbs,
+// apiGroups/resources/resourceNames (for resource rules) or nonResourceURLs
+// (for non-resource rules).
+func explainRule(rule rbacv1.PolicyRule) []RuleExplanation {
+	var explanations []RuleExplanation
+
+	verbs := rule.Verbs
+	if len(verbs) == 0 {
+		verbs = []string{""}
+	}
+
+	if len(rule.NonResourceURLs) > 0 {
+		for _, verb := range verbs {
+			for _, url := range rule.NonResourceURLs {
+				explanations = append(explanations, RuleExplanation{
+					Verb:           verb,
+					NonResourceURL: url,
+				})
+			}
+		}
+		return explanations
+	}
+
+	groups := rule.APIGroups
+	if len(groups) == 0 {
+		groups = []string{""}
+	}
+	resources := rule.Resources
+	if len(resources) == 0 {
+		resources = []string{""}
+	}
+	names := rule.ResourceNames
+	if len(names) == 0 {
+		names = []string{""}
+	}
+
+	for _, verb := range verbs {
+		for _, group := range groups {
+			for _, resource := range resources {
+				for _, name := range names {
+					explanations = append(explanations, RuleExplanation{
+						Verb:         verb,
+						APIGroup:     group,
+						Resource:     resource,
+						ResourceName: name,
+					})
+				}
+			}
+		}
+	}
+
+	return explanations
+}
+
+// runMode dispatches a decoded ValidationInput to the handler for its Mode,
+// defaulting to "covers" when unset.
+func runMode(input ValidationInput) ValidationOutput {
+	switch input.Mode {
+	case "", "covers":
+		return runCoversMode(input)
+	case "escalation":
+		return runEscalationMode(input)
+	case "query":
+		return runQueryMode(input)
+	default:
+		return ValidationOutput{
+			Covers: false,
+			Error:  fmt.Sprintf("Unknown mode: %q", input.Mode),
+		}
+	}
+}
+
+// runCoversMode implements the default "covers" mode: checking that
+// ReferenceRules grants everything requested by UserRules. If UserRefs or
+// ReferenceRefs are set, the corresponding rule set is resolved from the
+// given bindings first.
+func runCoversMode(input ValidationInput) ValidationOutput {
+	userRules := input.UserRules
+	referenceRules := input.ReferenceRules
+
+	var resolvedUser, resolvedReference []rbacv1.PolicyRule
+	if input.UserRefs != nil {
+		resolvedUser = resolveEffectiveRules(*input.UserRefs)
+		userRules = resolvedUser
+	}
+	if input.ReferenceRefs != nil {
+		resolvedReference = resolveEffectiveRules(*input.ReferenceRefs)
+		referenceRules = resolvedReference
+	}
+
+	compactedUser := CompactRules(userRules)
+	compactedReference := CompactRules(referenceRules)
+
+	referenceForCovers := compactedReference
+	if input.SubresourceWildcards {
+		referenceForCovers = expandSubresourceWildcards(compactedReference, compactedUser)
+	}
+	covers, uncovered := validation.Covers(referenceForCovers, compactedUser)
+
+	output := ValidationOutput{
+		Covers:                 covers,
+		UncoveredRules:         uncovered,
+		ResolvedUserRules:      resolvedUser,
+		ResolvedReferenceRules: resolvedReference,
+		CompactedUser:          compactedUser,
+		CompactedReference:     compactedReference,
+	}
+
+	if !covers {
+		output.Diagnostics = diagnoseUncoveredRules(uncovered)
+		output.Reasons = classifyReasons(uncovered, referenceForCovers)
+	}
+
+	if input.Explain {
+		for _, rule := range uncovered {
+			output.ExplainedRules = append(output.ExplainedRules, explainRule(rule)...)
+		}
+		if !covers {
+			output.RuleDiagnoses = explainUncoveredRules(uncovered, referenceForCovers)
+		}
+	}
+
+	return output
+}
+
+var (
+	userFileFlag           = flag.String("user-file", "", "path to a Role/ClusterRole manifest (or multi-doc YAML stream) to use as userRules")
+	referenceFileFlag      = flag.String("reference-file", "", "path to a Role/ClusterRole manifest (or multi-doc YAML stream) to use as referenceRules")
+	referencePolicyDirFlag = flag.String("reference-policy-dir", "", "directory of ClusterRole manifests (one persona per file) to use as a named aggregate reference policy; requires -reference-policy-name")
+	referencePolicyName    = flag.String("reference-policy-name", "", "name of the ClusterRole within -reference-policy-dir whose aggregated rules become referenceRules")
+	explainFlag            = flag.Bool("explain", false, "break down uncovered rules into atomic tuples and name the closest reference rule for each")
+	walkDirFlag            = flag.String("walk-dir", "", "recursively discover every Role/ClusterRole/RoleBinding/ClusterRoleBinding under this tenant RBAC manifest tree and validate every subject's effective rules against -reference-file/-reference-policy-dir")
+	walkJUnitOutFlag       = flag.String("walk-junit-out", "", "path to also write a JUnit-XML report for -walk-dir")
+	exportRegoFlag         = flag.String("export-rego", "", "render -reference-file/-reference-policy-dir as a Rego policy usable with conftest/OPA and write it to this path, instead of validating anything")
+	exportRegoPackageFlag  = flag.String("export-rego-package", "rbacvalidator", "Rego package name for -export-rego")
+	exportKyvernoFlag      = flag.String("export-kyverno", "", "render -reference-file/-reference-policy-dir as a Kyverno ClusterPolicy and write it to this path, instead of validating anything")
+	exportKyvernoNameFlag  = flag.String("export-kyverno-name", "rbac-reference-policy", "metadata.name for -export-kyverno")
+)
+
+// runFileMode builds a ValidationInput from manifests loaded via
+// -user-file/-reference-file/-reference-policy-dir instead of a JSON
+// ValidationInput on stdin, so CI can point the validator straight at the
+// YAML files in components/konflux-rbac/ without a preprocessor.
+func runFileMode(userFile, referenceFile, referencePolicyDir, referencePolicyName string) ValidationOutput {
+	input := ValidationInput{Explain: *explainFlag}
+
+	if userFile != "" {
+		loaded, err := loadRolesFromFile(userFile)
+		if err != nil {
+			return ValidationOutput{Error: fmt.Sprintf("Error loading -user-file: %v", err)}
+		}
+		input.UserRules = loaded.policyRules()
+	}
+
+	if referenceFile != "" || referencePolicyDir != "" {
+		rules, err := loadReferenceRulesFromFlags(referenceFile, referencePolicyDir, referencePolicyName)
+		if err != nil {
+			return ValidationOutput{Error: err.Error()}
+		}
+		input.ReferenceRules = rules
+	}
+
+	return runMode(input)
 }
 
 func main() {
-	var input ValidationInput
+	flag.Parse()
+
+	if *exportRegoFlag != "" || *exportKyvernoFlag != "" {
+		runExportCLI(exportOptions{
+			referenceFile:       *referenceFileFlag,
+			referencePolicyDir:  *referencePolicyDirFlag,
+			referencePolicyName: *referencePolicyName,
+			regoOut:             *exportRegoFlag,
+			regoPackage:         *exportRegoPackageFlag,
+			kyvernoOut:          *exportKyvernoFlag,
+			kyvernoName:         *exportKyvernoNameFlag,
+		})
+		return
+	}
 
-	if err := json.NewDecoder(os.Stdin).Decode(&input); err != nil {
+	if *walkDirFlag != "" {
+		runWalkModeCLI(*walkDirFlag, *referenceFileFlag, *referencePolicyDirFlag, *referencePolicyName, *walkJUnitOutFlag)
+		return
+	}
+
+	if *userFileFlag != "" || *referenceFileFlag != "" || *referencePolicyDirFlag != "" {
+		output := runFileMode(*userFileFlag, *referenceFileFlag, *referencePolicyDirFlag, *referencePolicyName)
+		if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
+			os.Exit(1)
+		}
+		if output.Error != "" {
+			os.Exit(1)
+		}
+		return
+	}
+
+	raw, err := io.ReadAll(os.Stdin)
+	if err != nil {
 		output := ValidationOutput{
 			Covers: false,
-			Error:  fmt.Sprintf("Error decoding input: %v", err),
+			Error:  fmt.Sprintf("Error reading input: %v", err),
 		}
 		_ = json.NewEncoder(os.Stdout).Encode(output)
 		os.Exit(1)
 	}
 
-	// Use Kubernetes validation logic
-	covers, _ := validation.Covers(input.ReferenceRules, input.UserRules)
+	if batchInput, ok := sniffBatchInput(raw); ok {
+		results, err := runBatch(batchInput.Items, *parallelFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error validating batch: %v\n", err)
+			_ = json.NewEncoder(os.Stdout).Encode(results)
+			os.Exit(1)
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
+			os.Exit(1)
+		}
+		if anyDenied(results) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var input ValidationInput
+	if err := json.Unmarshal(raw, &input); err != nil {
+		output := ValidationOutput{
+			Covers: false,
+			Error:  fmt.Sprintf("Error decoding input: %v", err),
+		}
+		_ = json.NewEncoder(os.Stdout).Encode(output)
+		os.Exit(1)
+	}
 
-	output := ValidationOutput{
-		Covers: covers,
+	if *explainFlag {
+		input.Explain = true
 	}
 
+	output := runMode(input)
+
 	if err := json.NewEncoder(os.Stdout).Encode(output); err != nil {
 		fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
 		os.Exit(1)
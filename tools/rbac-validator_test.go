@@ -339,6 +339,71 @@ func TestValidationInputOutput(t *testing.T) {
 	}
 }
 
+func TestNonResourceURLCoverage(t *testing.T) {
+	// Modeled on the operator-builder rbac work, which splits resource and
+	// non-resource rule handling: ClusterRoles that grant /healthz, /metrics,
+	// /api/* etc. have no Resources/APIGroups at all, only NonResourceURLs.
+	tests := []struct {
+		name           string
+		userRules      []rbacv1.PolicyRule
+		referenceRules []rbacv1.PolicyRule
+		expectedCovers bool
+	}{
+		{
+			name: "exact nonResourceURL match",
+			userRules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/healthz", "/metrics"}, Verbs: []string{"get"}},
+			},
+			expectedCovers: true,
+		},
+		{
+			name: "nonResourceURL covered by prefix wildcard",
+			userRules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/apis/foo"}, Verbs: []string{"get"}},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/apis/*"}, Verbs: []string{"get"}},
+			},
+			expectedCovers: true,
+		},
+		{
+			name: "nonResourceURL not granted by any reference rule",
+			userRules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+			},
+			expectedCovers: false,
+		},
+		{
+			name: "nonResourceURL verb not granted",
+			userRules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"post"}},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}},
+			},
+			expectedCovers: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runCoversMode(ValidationInput{UserRules: tt.userRules, ReferenceRules: tt.referenceRules})
+			if output.Covers != tt.expectedCovers {
+				t.Errorf("Covers = %v, want %v", output.Covers, tt.expectedCovers)
+			}
+			if !tt.expectedCovers && len(output.Diagnostics) == 0 {
+				t.Error("expected diagnostics for a denied nonResourceURL rule")
+			}
+		})
+	}
+}
+
 func TestComplexMultiAPIGroupRole(t *testing.T) {
 	// Test based on actual konflux-admin-user-actions role from infra-deployments
 	// https://github.com/redhat-appstudio/infra-deployments/blob/main/components/konflux-rbac/production/base/konflux-admin-user-actions.yaml
@@ -709,7 +774,7 @@ func TestRealWorldKonfluxRoles(t *testing.T) {
 
 func TestBinaryIntegration(t *testing.T) {
 	// Build the binary first
-	cmd := exec.Command("go", "build", "-o", "rbac-validator-test", "rbac-validator.go")
+	cmd := exec.Command("go", "build", "-o", "rbac-validator-test", ".")
 	if err := cmd.Run(); err != nil {
 		t.Fatalf("Failed to build binary: %v", err)
 	}
@@ -807,9 +872,155 @@ func TestBinaryIntegration(t *testing.T) {
 	}
 }
 
+func TestExplainMode(t *testing.T) {
+	input := ValidationInput{
+		UserRules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"secrets"},
+				Verbs:     []string{"delete"},
+			},
+		},
+		ReferenceRules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"secrets"},
+				Verbs:     []string{"get", "list"},
+			},
+		},
+		Explain: true,
+	}
+
+	covers, uncovered := validation.Covers(input.ReferenceRules, input.UserRules)
+	if covers {
+		t.Fatal("expected covers=false for a delete-only user rule against a get/list reference rule")
+	}
+	if len(uncovered) == 0 {
+		t.Fatal("expected at least one uncovered rule")
+	}
+
+	var explained []RuleExplanation
+	for _, rule := range uncovered {
+		explained = append(explained, explainRule(rule)...)
+	}
+
+	found := false
+	for _, e := range explained {
+		if e.Verb == "delete" && e.APIGroup == "" && e.Resource == "secrets" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected explained rules to include verb=delete apiGroup=\"\" resource=secrets, got %+v", explained)
+	}
+}
+
+func TestExplainModeBinaryIntegration(t *testing.T) {
+	cmd := exec.Command("go", "build", "-o", "rbac-validator-test-explain", ".")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Failed to build binary: %v", err)
+	}
+	defer func() { _ = os.Remove("rbac-validator-test-explain") }()
+
+	input := ValidationInput{
+		UserRules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"secrets"},
+				Verbs:     []string{"delete"},
+			},
+		},
+		ReferenceRules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"secrets"},
+				Verbs:     []string{"get", "list"},
+			},
+		},
+		Explain: true,
+	}
+
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		t.Fatalf("Failed to marshal input: %v", err)
+	}
+
+	cmd = exec.Command("./rbac-validator-test-explain")
+	cmd.Stdin = bytes.NewReader(inputJSON)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("Unexpected error: %v, stderr: %s", err, stderr.String())
+	}
+
+	var output ValidationOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		t.Fatalf("Failed to unmarshal output: %v, stdout: %s", err, stdout.String())
+	}
+
+	if output.Covers {
+		t.Error("expected covers=false")
+	}
+	if len(output.UncoveredRules) == 0 {
+		t.Error("expected UncoveredRules to be populated")
+	}
+	if len(output.ExplainedRules) == 0 {
+		t.Error("expected ExplainedRules to be populated when Explain is true")
+	}
+}
+
+func TestDiagnosticsOnDenial(t *testing.T) {
+	output := runCoversMode(ValidationInput{
+		UserRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"delete"}},
+		},
+		ReferenceRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list"}},
+		},
+	})
+
+	if output.Covers {
+		t.Fatal("expected covers=false")
+	}
+	if len(output.Diagnostics) == 0 {
+		t.Fatal("expected diagnostics to be populated on a denied result")
+	}
+
+	want := `verb "delete" on resource "secrets" in group "" not granted by any reference rule`
+	found := false
+	for _, d := range output.Diagnostics {
+		if d == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected diagnostics to include %q, got %v", want, output.Diagnostics)
+	}
+}
+
+func TestDiagnosticsNotPopulatedOnAllow(t *testing.T) {
+	output := runCoversMode(ValidationInput{
+		UserRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+		},
+		ReferenceRules: []rbacv1.PolicyRule{
+			{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+		},
+	})
+
+	if !output.Covers {
+		t.Fatal("expected covers=true")
+	}
+	if len(output.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics on an allowed result, got %v", output.Diagnostics)
+	}
+}
+
 func TestInvalidJSON(t *testing.T) {
 	// Build the binary first
-	cmd := exec.Command("go", "build", "-o", "rbac-validator-test", "rbac-validator.go")
+	cmd := exec.Command("go", "build", "-o", "rbac-validator-test", ".")
 	if err := cmd.Run(); err != nil {
 		t.Fatalf("Failed to build binary: %v", err)
 	}
@@ -0,0 +1,94 @@
+package main
+
+import rbacv1 "k8s.io/api/rbac/v1"
+
+// Reason codes classifying why a rule went uncovered. Stable and
+// machine-readable so a batch-validation CI job can branch on *why* a
+// tenant role needs more reference permissions without parsing Diagnostics'
+// prose.
+const (
+	ReasonExtraAPIGroup            = "EXTRA_APIGROUP"
+	ReasonExtraResource            = "EXTRA_RESOURCE"
+	ReasonExtraVerb                = "EXTRA_VERB"
+	ReasonExtraResourceName        = "EXTRA_RESOURCE_NAME"
+	ReasonNonResourceURLNotGranted = "NONRESOURCE_URL_NOT_GRANTED"
+)
+
+// classifyReasons turns the raw uncovered PolicyRule set into a
+// deduplicated, stable set of reason codes, one per rule in uncovered.
+func classifyReasons(uncovered, referenceRules []rbacv1.PolicyRule) []string {
+	seen := make(map[string]struct{})
+	var reasons []string
+	for _, rule := range uncovered {
+		reason := classifyUncoveredRule(rule, referenceRules)
+		if _, ok := seen[reason]; ok {
+			continue
+		}
+		seen[reason] = struct{}{}
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+// classifyUncoveredRule assigns a reason code to a single uncovered rule by
+// checking how far a reference rule gets before failing to match it:
+// apiGroup, then resource, then verb, then resourceName. The first stage
+// that no reference rule clears is the reason the rule went uncovered.
+func classifyUncoveredRule(rule rbacv1.PolicyRule, referenceRules []rbacv1.PolicyRule) string {
+	if len(rule.NonResourceURLs) > 0 {
+		return ReasonNonResourceURLNotGranted
+	}
+
+	groups := rule.APIGroups
+	if len(groups) == 0 {
+		groups = []string{""}
+	}
+	resources := rule.Resources
+	if len(resources) == 0 {
+		resources = []string{""}
+	}
+
+	var groupGranted, resourceGranted, verbGranted bool
+	for _, refRule := range referenceRules {
+		if len(refRule.NonResourceURLs) > 0 || !anyGrants(refRule.APIGroups, groups) {
+			continue
+		}
+		groupGranted = true
+		if !anyGrants(refRule.Resources, resources) {
+			continue
+		}
+		resourceGranted = true
+		if !anyGrants(refRule.Verbs, rule.Verbs) {
+			continue
+		}
+		verbGranted = true
+	}
+
+	switch {
+	case !groupGranted:
+		return ReasonExtraAPIGroup
+	case !resourceGranted:
+		return ReasonExtraResource
+	case !verbGranted:
+		return ReasonExtraVerb
+	default:
+		return ReasonExtraResourceName
+	}
+}
+
+// anyGrants reports whether granted (a reference rule's APIGroups,
+// Resources, or Verbs) covers any entry of want, treating "*" as matching
+// everything.
+func anyGrants(granted, want []string) bool {
+	for _, g := range granted {
+		if g == rbacv1.ResourceAll {
+			return true
+		}
+		for _, w := range want {
+			if g == w {
+				return true
+			}
+		}
+	}
+	return false
+}
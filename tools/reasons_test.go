@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestClassifyReasons(t *testing.T) {
+	tests := []struct {
+		name           string
+		userRules      []rbacv1.PolicyRule
+		referenceRules []rbacv1.PolicyRule
+		wantReason     string
+	}{
+		{
+			name: "apiGroup not granted at all",
+			userRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"networking.k8s.io"}, Resources: []string{"networkpolicies"}, Verbs: []string{"get"}},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+			},
+			wantReason: ReasonExtraAPIGroup,
+		},
+		{
+			name: "resource not granted within the apiGroup",
+			userRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+			},
+			wantReason: ReasonExtraResource,
+		},
+		{
+			name: "verb not granted for an otherwise-granted resource",
+			userRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"delete"}},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list"}},
+			},
+			wantReason: ReasonExtraVerb,
+		},
+		{
+			name: "resourceName not granted though verb/resource/group are",
+			userRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"db-password"}, Verbs: []string{"get"}},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"other-secret"}, Verbs: []string{"get"}},
+			},
+			wantReason: ReasonExtraResourceName,
+		},
+		{
+			name: "nonResourceURL not granted",
+			userRules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+			},
+			wantReason: ReasonNonResourceURLNotGranted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runCoversMode(ValidationInput{UserRules: tt.userRules, ReferenceRules: tt.referenceRules})
+			if output.Covers {
+				t.Fatal("expected covers=false")
+			}
+			if len(output.Reasons) != 1 || output.Reasons[0] != tt.wantReason {
+				t.Errorf("Reasons = %v, want [%s]", output.Reasons, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestClassifyReasonsNotPopulatedOnAllow(t *testing.T) {
+	output := runCoversMode(ValidationInput{
+		UserRules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		ReferenceRules: []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+	})
+	if len(output.Reasons) != 0 {
+		t.Errorf("expected no reasons on an allowed result, got %v", output.Reasons)
+	}
+}
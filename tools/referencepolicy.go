@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// loadRolesFromDir reads every *.yaml/*.yml file directly under dir and
+// merges everything found into a single LoadedRoles, the same as if they'd
+// all been concatenated into one multi-document stream. This lets a
+// reference policy be authored as one ClusterRole per file (viewer.yaml,
+// contributor.yaml, maintainer.yaml) instead of one big stream, while still
+// letting clusterRoleRules resolve AggregationRule selectors across all of
+// them - mirroring how upstream bootstrappolicy defines admin/edit/view as
+// separate, mutually-aggregating ClusterRoles.
+func loadRolesFromDir(dir string) (LoadedRoles, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return LoadedRoles{}, err
+	}
+
+	var out LoadedRoles
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".yaml") && !strings.HasSuffix(name, ".yml") {
+			continue
+		}
+		loaded, err := loadRolesFromFile(filepath.Join(dir, name))
+		if err != nil {
+			return LoadedRoles{}, fmt.Errorf("loading %s: %w", name, err)
+		}
+		out.Roles = append(out.Roles, loaded.Roles...)
+		out.ClusterRoles = append(out.ClusterRoles, loaded.ClusterRoles...)
+	}
+	return out, nil
+}
+
+// namedAggregatePolicyRules returns the effective rule set for the
+// ClusterRole named name within loaded: its own rules, plus everything any
+// sibling ClusterRole in loaded aggregates into it via AggregationRule. This
+// is the "named aggregate" a reference-policy directory exposes - e.g.
+// picking out just the "contributor" persona's rules rather than the union
+// of every ClusterRole in the directory, which is what LoadedRoles.policyRules
+// would give you.
+func namedAggregatePolicyRules(loaded LoadedRoles, name string) ([]rbacv1.PolicyRule, error) {
+	for _, cr := range loaded.ClusterRoles {
+		if cr.Name == name {
+			return clusterRoleRules(cr, loaded.ClusterRoles), nil
+		}
+	}
+	return nil, fmt.Errorf("no ClusterRole named %q found", name)
+}
+
+// loadReferenceRulesFromFlags resolves a reference rule set from either
+// -reference-file (a single manifest/stream) or -reference-policy-dir plus
+// -reference-policy-name (a named aggregate persona), the two ways the CLI
+// lets a caller specify referenceRules without JSON on stdin. Shared by
+// file mode and walk mode so both pick a reference policy the same way.
+func loadReferenceRulesFromFlags(referenceFile, referencePolicyDir, referencePolicyName string) ([]rbacv1.PolicyRule, error) {
+	if referenceFile != "" {
+		loaded, err := loadRolesFromFile(referenceFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading -reference-file: %w", err)
+		}
+		return loaded.policyRules(), nil
+	}
+
+	if referencePolicyName == "" {
+		return nil, fmt.Errorf("-reference-policy-dir requires -reference-policy-name")
+	}
+	loaded, err := loadRolesFromDir(referencePolicyDir)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading -reference-policy-dir: %w", err)
+	}
+	rules, err := namedAggregatePolicyRules(loaded, referencePolicyName)
+	if err != nil {
+		return nil, fmt.Errorf("Error resolving -reference-policy-name: %w", err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestManifest(t *testing.T, dir, name, contents string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+}
+
+func TestLoadRolesFromDirAndNamedAggregate(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestManifest(t, dir, "viewer.yaml", `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: viewer
+  labels:
+    rbac.example.com/aggregate-to-contributor: "true"
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+`)
+	writeTestManifest(t, dir, "contributor.yaml", `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: contributor
+aggregationRule:
+  clusterRoleSelectors:
+  - matchLabels:
+      rbac.example.com/aggregate-to-contributor: "true"
+rules:
+- apiGroups: [""]
+  resources: ["configmaps"]
+  verbs: ["create"]
+`)
+
+	loaded, err := loadRolesFromDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded.ClusterRoles) != 2 {
+		t.Fatalf("expected 2 ClusterRoles, got %d", len(loaded.ClusterRoles))
+	}
+
+	rules, err := namedAggregatePolicyRules(loaded, "contributor")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected contributor's own rule plus viewer's aggregated rule, got %+v", rules)
+	}
+
+	// The viewer persona alone must not also carry contributor's rules.
+	viewerRules, err := namedAggregatePolicyRules(loaded, "viewer")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(viewerRules) != 1 || viewerRules[0].Resources[0] != "pods" {
+		t.Errorf("expected viewer to only carry its own rule, got %+v", viewerRules)
+	}
+}
+
+func TestNamedAggregatePolicyRulesUnknownName(t *testing.T) {
+	_, err := namedAggregatePolicyRules(LoadedRoles{}, "maintainer")
+	if err == nil {
+		t.Error("expected an error for an unknown reference policy name")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ResolveInput bundles the raw RBAC objects needed to compute a subject's
+// effective PolicyRule set, the way the kube-apiserver's RBAC authorizer and
+// the ClusterRole aggregation controller do: walk every binding that
+// references Subject, collect the Role/ClusterRole each one points at, and
+// union in aggregated ClusterRoles by label selector.
+type ResolveInput struct {
+	Subject             rbacv1.Subject              `json:"subject"`
+	Roles               []rbacv1.Role               `json:"roles,omitempty"`
+	ClusterRoles        []rbacv1.ClusterRole        `json:"clusterRoles,omitempty"`
+	RoleBindings        []rbacv1.RoleBinding        `json:"roleBindings,omitempty"`
+	ClusterRoleBindings []rbacv1.ClusterRoleBinding `json:"clusterRoleBindings,omitempty"`
+}
+
+// subjectMatches reports whether binding subject s refers to the same
+// subject as want, comparing Kind/Name and, for ServiceAccounts, Namespace.
+func subjectMatches(s, want rbacv1.Subject) bool {
+	if s.Kind != want.Kind || s.Name != want.Name {
+		return false
+	}
+	if s.Kind == rbacv1.ServiceAccountKind && s.Namespace != want.Namespace {
+		return false
+	}
+	return true
+}
+
+// clusterRoleRules returns a ClusterRole's own rules, plus, when it carries
+// an AggregationRule, the rules of every other ClusterRole in all whose
+// labels match one of the AggregationRule's selectors. This mirrors the
+// one-level aggregation the in-cluster aggregation controller performs; it
+// does not recurse into the matched ClusterRoles' own AggregationRules.
+func clusterRoleRules(cr rbacv1.ClusterRole, all []rbacv1.ClusterRole) []rbacv1.PolicyRule {
+	rules := append([]rbacv1.PolicyRule{}, cr.Rules...)
+
+	if cr.AggregationRule == nil {
+		return rules
+	}
+
+	for _, rawSelector := range cr.AggregationRule.ClusterRoleSelectors {
+		selector, err := metav1.LabelSelectorAsSelector(&rawSelector) // #nosec G601 - range value copied intentionally
+		if err != nil {
+			continue
+		}
+		for _, candidate := range all {
+			if candidate.Name == cr.Name {
+				continue
+			}
+			if selector.Matches(labels.Set(candidate.Labels)) {
+				rules = append(rules, candidate.Rules...)
+			}
+		}
+	}
+
+	return rules
+}
+
+// resolveEffectiveRulesInNamespace is resolveEffectiveRules, but first drops
+// any RoleBinding not in namespace -- so rules only granted by a namespaced
+// RoleBinding elsewhere don't leak into the result. ClusterRoleBindings are
+// left untouched since they grant cluster-wide regardless of namespace. An
+// empty namespace disables the filter and behaves exactly like
+// resolveEffectiveRules.
+func resolveEffectiveRulesInNamespace(in ResolveInput, namespace string) []rbacv1.PolicyRule {
+	if namespace == "" {
+		return resolveEffectiveRules(in)
+	}
+
+	scoped := in
+	scoped.RoleBindings = nil
+	for _, binding := range in.RoleBindings {
+		if binding.Namespace == namespace {
+			scoped.RoleBindings = append(scoped.RoleBindings, binding)
+		}
+	}
+	return resolveEffectiveRules(scoped)
+}
+
+// resolveEffectiveRules walks every RoleBinding and ClusterRoleBinding in in
+// that references in.Subject, resolves each to its Role/ClusterRole, and
+// returns the union of their rules (expanding ClusterRole aggregation along
+// the way).
+func resolveEffectiveRules(in ResolveInput) []rbacv1.PolicyRule {
+	var effective []rbacv1.PolicyRule
+
+	for _, binding := range in.RoleBindings {
+		if !bindingMatchesSubject(binding.Subjects, in.Subject) {
+			continue
+		}
+		switch binding.RoleRef.Kind {
+		case "Role":
+			for _, role := range in.Roles {
+				if role.Name == binding.RoleRef.Name && role.Namespace == binding.Namespace {
+					effective = append(effective, role.Rules...)
+				}
+			}
+		case "ClusterRole":
+			for _, cr := range in.ClusterRoles {
+				if cr.Name == binding.RoleRef.Name {
+					effective = append(effective, clusterRoleRules(cr, in.ClusterRoles)...)
+				}
+			}
+		}
+	}
+
+	for _, binding := range in.ClusterRoleBindings {
+		if !bindingMatchesSubject(binding.Subjects, in.Subject) {
+			continue
+		}
+		for _, cr := range in.ClusterRoles {
+			if cr.Name == binding.RoleRef.Name {
+				effective = append(effective, clusterRoleRules(cr, in.ClusterRoles)...)
+			}
+		}
+	}
+
+	return effective
+}
+
+func bindingMatchesSubject(subjects []rbacv1.Subject, want rbacv1.Subject) bool {
+	for _, s := range subjects {
+		if subjectMatches(s, want) {
+			return true
+		}
+	}
+	return false
+}
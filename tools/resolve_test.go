@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveEffectiveRulesRoleBinding(t *testing.T) {
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "releaser", Namespace: "tenant-a"}
+
+	in := ResolveInput{
+		Subject: subject,
+		Roles: []rbacv1.Role{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "releaser-role", Namespace: "tenant-a"},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{"appstudio.redhat.com"}, Resources: []string{"releases"}, Verbs: []string{"get", "list"}},
+				},
+			},
+		},
+		RoleBindings: []rbacv1.RoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "releaser-binding", Namespace: "tenant-a"},
+				Subjects:   []rbacv1.Subject{subject},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "releaser-role"},
+			},
+		},
+	}
+
+	rules := resolveEffectiveRules(in)
+	if len(rules) != 1 || rules[0].Resources[0] != "releases" {
+		t.Fatalf("expected to resolve the bound Role's rules, got %+v", rules)
+	}
+}
+
+func TestResolveEffectiveRulesAggregatedClusterRole(t *testing.T) {
+	subject := rbacv1.Subject{Kind: "User", Name: "admin"}
+
+	in := ResolveInput{
+		Subject: subject,
+		ClusterRoles: []rbacv1.ClusterRole{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "aggregate-view"},
+				AggregationRule: &rbacv1.AggregationRule{
+					ClusterRoleSelectors: []metav1.LabelSelector{
+						{MatchLabels: map[string]string{"rbac.example.com/aggregate-to-view": "true"}},
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "pods-view",
+					Labels: map[string]string{"rbac.example.com/aggregate-to-view": "true"},
+				},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+				},
+			},
+		},
+		ClusterRoleBindings: []rbacv1.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "admin-aggregate-view"},
+				Subjects:   []rbacv1.Subject{subject},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "aggregate-view"},
+			},
+		},
+	}
+
+	rules := resolveEffectiveRules(in)
+	if len(rules) != 1 || rules[0].Resources[0] != "pods" {
+		t.Fatalf("expected aggregation to pull in pods-view's rules, got %+v", rules)
+	}
+}
+
+func TestResolveEffectiveRulesNoMatchingSubject(t *testing.T) {
+	in := ResolveInput{
+		Subject: rbacv1.Subject{Kind: "User", Name: "someone-else"},
+		ClusterRoles: []rbacv1.ClusterRole{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+				Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+			},
+		},
+		ClusterRoleBindings: []rbacv1.ClusterRoleBinding{
+			{
+				Subjects: []rbacv1.Subject{{Kind: "User", Name: "admin"}},
+				RoleRef:  rbacv1.RoleRef{Kind: "ClusterRole", Name: "admin"},
+			},
+		},
+	}
+
+	if rules := resolveEffectiveRules(in); len(rules) != 0 {
+		t.Errorf("expected no rules for a subject with no matching binding, got %+v", rules)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// expandSubresourceWildcards returns referenceRules with extra synthetic
+// rules appended so validation.Covers's exact resource matching also honors
+// subresource wildcard patterns: a reference rule naming "pods/*" covers any
+// "pods/<subresource>", and one naming "*/scale" covers any
+// "<resource>/scale". For every reference rule containing such a pattern,
+// and every concrete resource requested by userRules that the pattern would
+// match, a copy of the reference rule naming that exact resource is added -
+// letting validation.Covers's own exact-match logic take it from there.
+func expandSubresourceWildcards(referenceRules, userRules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	requested := requestedResources(userRules)
+
+	expanded := make([]rbacv1.PolicyRule, len(referenceRules))
+	copy(expanded, referenceRules)
+
+	for _, rule := range referenceRules {
+		for _, pattern := range rule.Resources {
+			if !isSubresourceWildcard(pattern) {
+				continue
+			}
+			for _, resource := range requested {
+				if !subresourceWildcardMatches(pattern, resource) {
+					continue
+				}
+				clone := rule.DeepCopy()
+				clone.Resources = []string{resource}
+				expanded = append(expanded, *clone)
+			}
+		}
+	}
+	return expanded
+}
+
+// requestedResources returns the deduplicated set of Resources named across
+// rules, in first-seen order.
+func requestedResources(rules []rbacv1.PolicyRule) []string {
+	seen := make(map[string]struct{})
+	var out []string
+	for _, rule := range rules {
+		for _, resource := range rule.Resources {
+			if _, ok := seen[resource]; ok {
+				continue
+			}
+			seen[resource] = struct{}{}
+			out = append(out, resource)
+		}
+	}
+	return out
+}
+
+// isSubresourceWildcard reports whether pattern is a two-segment resource
+// name with exactly one wildcarded segment, e.g. "pods/*" or "*/scale". A
+// bare "*" (full wildcard, handled separately by validation.Covers) and
+// "*/*" don't qualify.
+func isSubresourceWildcard(pattern string) bool {
+	resource, sub, ok := strings.Cut(pattern, "/")
+	if !ok {
+		return false
+	}
+	return (resource == rbacv1.ResourceAll) != (sub == rbacv1.ResourceAll)
+}
+
+// subresourceWildcardMatches reports whether resource (e.g. "deployments/scale")
+// matches pattern (e.g. "*/scale" or "deployments/*"), segment by segment.
+func subresourceWildcardMatches(pattern, resource string) bool {
+	patternResource, patternSub, ok := strings.Cut(pattern, "/")
+	if !ok {
+		return false
+	}
+	resourceName, resourceSub, ok := strings.Cut(resource, "/")
+	if !ok {
+		return false
+	}
+	return (patternResource == rbacv1.ResourceAll || patternResource == resourceName) &&
+		(patternSub == rbacv1.ResourceAll || patternSub == resourceSub)
+}
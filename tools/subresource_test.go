@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestSubresourceWildcardCoverage(t *testing.T) {
+	tests := []struct {
+		name           string
+		userRules      []rbacv1.PolicyRule
+		referenceRules []rbacv1.PolicyRule
+		expectedCovers bool
+	}{
+		{
+			name: "resource/* covers a specific subresource",
+			userRules: []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{""},
+					Resources: []string{"pods/log"},
+					Verbs:     []string{"get"},
+				},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{""},
+					Resources: []string{"pods/*"},
+					Verbs:     []string{"*"},
+				},
+			},
+			expectedCovers: true,
+		},
+		{
+			name: "*/subresource covers the same subresource on any resource",
+			userRules: []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{"apps"},
+					Resources: []string{"deployments/scale"},
+					Verbs:     []string{"update"},
+				},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{"apps"},
+					Resources: []string{"*/scale"},
+					Verbs:     []string{"*"},
+				},
+			},
+			expectedCovers: true,
+		},
+		{
+			name: "resource/* does not cover an unrelated resource's subresource",
+			userRules: []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{""},
+					Resources: []string{"nodes/status"},
+					Verbs:     []string{"get"},
+				},
+			},
+			referenceRules: []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{""},
+					Resources: []string{"pods/*"},
+					Verbs:     []string{"*"},
+				},
+			},
+			expectedCovers: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runCoversMode(ValidationInput{
+				UserRules:            tt.userRules,
+				ReferenceRules:       tt.referenceRules,
+				SubresourceWildcards: true,
+			})
+			if output.Covers != tt.expectedCovers {
+				t.Errorf("Covers = %v, want %v", output.Covers, tt.expectedCovers)
+			}
+		})
+	}
+}
+
+func TestSubresourceWildcardOffByDefault(t *testing.T) {
+	// Same "pods/*" reference rule as above, but without SubresourceWildcards
+	// set: must reproduce the literal, non-wildcard behavior documented in
+	// TestValidationInputOutput's "subresources access - wildcard doesn't
+	// work as expected" case.
+	output := runCoversMode(ValidationInput{
+		UserRules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods/log"},
+				Verbs:     []string{"get"},
+			},
+		},
+		ReferenceRules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods/*"},
+				Verbs:     []string{"*"},
+			},
+		},
+	})
+	if output.Covers {
+		t.Error("expected Covers = false without SubresourceWildcards set")
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/component-helpers/auth/rbac/validation"
+)
+
+// WalkSubjectResult is the validation outcome for one subject discovered
+// while walking a tenant RBAC manifest tree: its effective rule set
+// (expanding every RoleBinding/ClusterRoleBinding and ClusterRole
+// aggregation that grants it something), checked against the reference
+// policy walk mode was given.
+type WalkSubjectResult struct {
+	Namespace      string              `json:"namespace"`
+	Subject        rbacv1.Subject      `json:"subject"`
+	Covers         bool                `json:"covers"`
+	UncoveredRules []rbacv1.PolicyRule `json:"uncoveredRules,omitempty"`
+	Diagnostics    []string            `json:"diagnostics,omitempty"`
+}
+
+// WalkReport is the machine-readable summary walk mode produces: one
+// WalkSubjectResult per subject discovered, keyed in practice by its
+// tenant Namespace, plus any structural errors - most importantly a
+// RoleBinding/ClusterRoleBinding referencing a Role/ClusterRole that was
+// never found in the tree - that couldn't be attributed to a subject.
+type WalkReport struct {
+	Subjects []WalkSubjectResult `json:"subjects"`
+	Errors   []string            `json:"errors,omitempty"`
+}
+
+// loadManifestTree recursively loads every *.yaml/*.yml file under root,
+// descending into Kustomize-style overlay subdirectories the same as any
+// other, and merges everything found - Roles, ClusterRoles, RoleBindings,
+// ClusterRoleBindings, across however many multi-document files they're
+// split across - into a single LoadedRoles.
+func loadManifestTree(root string) (LoadedRoles, error) {
+	var out LoadedRoles
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
+			return nil
+		}
+		loaded, err := loadRolesFromFile(path)
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", path, err)
+		}
+		out.Roles = append(out.Roles, loaded.Roles...)
+		out.ClusterRoles = append(out.ClusterRoles, loaded.ClusterRoles...)
+		out.RoleBindings = append(out.RoleBindings, loaded.RoleBindings...)
+		out.ClusterRoleBindings = append(out.ClusterRoleBindings, loaded.ClusterRoleBindings...)
+		return nil
+	})
+	return out, err
+}
+
+// subjectKey identifies a Subject for deduplication across every binding
+// that references it.
+func subjectKey(s rbacv1.Subject) string {
+	return strings.Join([]string{s.Kind, s.Namespace, s.Name}, "|")
+}
+
+// roleRefExists reports whether roleRef names a Role (scoped to namespace)
+// or ClusterRole actually present in loaded.
+func roleRefExists(roleRef rbacv1.RoleRef, namespace string, loaded LoadedRoles) bool {
+	switch roleRef.Kind {
+	case "Role":
+		for _, role := range loaded.Roles {
+			if role.Name == roleRef.Name && role.Namespace == namespace {
+				return true
+			}
+		}
+	case "ClusterRole":
+		for _, cr := range loaded.ClusterRoles {
+			if cr.Name == roleRef.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runWalkMode discovers every subject referenced by a RoleBinding or
+// ClusterRoleBinding under root, resolves each one's effective rule set
+// (expanding ClusterRole aggregation along the way via resolveEffectiveRules),
+// and validates it against referenceRules. A binding that references a
+// Role/ClusterRole never found in the tree is reported as a structural
+// error instead of silently skipped or treated as granting nothing.
+func runWalkMode(root string, referenceRules []rbacv1.PolicyRule) (WalkReport, error) {
+	loaded, err := loadManifestTree(root)
+	if err != nil {
+		return WalkReport{}, err
+	}
+
+	var report WalkReport
+	subjects := make(map[string]rbacv1.Subject)
+	namespaceOf := make(map[string]string)
+
+	for _, binding := range loaded.RoleBindings {
+		if !roleRefExists(binding.RoleRef, binding.Namespace, loaded) {
+			report.Errors = append(report.Errors, fmt.Sprintf(
+				"RoleBinding %s/%s references missing %s %q", binding.Namespace, binding.Name, binding.RoleRef.Kind, binding.RoleRef.Name))
+			continue
+		}
+		for _, subject := range binding.Subjects {
+			key := subjectKey(subject)
+			subjects[key] = subject
+			namespaceOf[key] = binding.Namespace
+		}
+	}
+	for _, binding := range loaded.ClusterRoleBindings {
+		if !roleRefExists(binding.RoleRef, "", loaded) {
+			report.Errors = append(report.Errors, fmt.Sprintf(
+				"ClusterRoleBinding %s references missing %s %q", binding.Name, binding.RoleRef.Kind, binding.RoleRef.Name))
+			continue
+		}
+		for _, subject := range binding.Subjects {
+			key := subjectKey(subject)
+			subjects[key] = subject
+			if _, ok := namespaceOf[key]; !ok {
+				namespaceOf[key] = ""
+			}
+		}
+	}
+
+	compactedReference := CompactRules(referenceRules)
+
+	for key, subject := range subjects {
+		effective := resolveEffectiveRules(ResolveInput{
+			Subject:             subject,
+			Roles:               loaded.Roles,
+			ClusterRoles:        loaded.ClusterRoles,
+			RoleBindings:        loaded.RoleBindings,
+			ClusterRoleBindings: loaded.ClusterRoleBindings,
+		})
+
+		covers, uncovered := validation.Covers(compactedReference, CompactRules(effective))
+
+		result := WalkSubjectResult{
+			Namespace: namespaceOf[key],
+			Subject:   subject,
+			Covers:    covers,
+		}
+		if !covers {
+			result.UncoveredRules = uncovered
+			result.Diagnostics = diagnoseUncoveredRules(uncovered)
+		}
+		report.Subjects = append(report.Subjects, result)
+	}
+
+	return report, nil
+}
+
+// runWalkModeCLI is the -walk-dir entry point from main: it loads the
+// reference policy from -reference-file/-reference-policy-dir, runs
+// runWalkMode, prints the JSON summary, optionally writes a JUnit-XML
+// report to junitOut, and exits non-zero if anything was denied or
+// structurally broken.
+func runWalkModeCLI(walkDir, referenceFile, referencePolicyDir, referencePolicyName, junitOut string) {
+	referenceRules, err := loadReferenceRulesFromFlags(referenceFile, referencePolicyDir, referencePolicyName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading reference policy: %v\n", err)
+		os.Exit(1)
+	}
+
+	report, err := runWalkMode(walkDir, referenceRules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error walking %s: %v\n", walkDir, err)
+		os.Exit(1)
+	}
+
+	if junitOut != "" {
+		xmlBytes, err := walkReportJUnitXML(report)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering JUnit report: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(junitOut, xmlBytes, 0o600); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JUnit report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding output: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(report.Errors) > 0 || anySubjectDenied(report) {
+		os.Exit(1)
+	}
+}
+
+// anySubjectDenied reports whether any subject in the report exceeded the
+// reference policy.
+func anySubjectDenied(report WalkReport) bool {
+	for _, result := range report.Subjects {
+		if !result.Covers {
+			return true
+		}
+	}
+	return false
+}
+
+// junitTestSuite and friends are the minimal JUnit-XML shape CI systems
+// already know how to render as PR annotations.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// walkReportJUnitXML renders a WalkReport as a JUnit-XML testsuite: one
+// testcase per subject (classname is its tenant namespace, or "cluster" for
+// a ClusterRoleBinding-only subject), plus one failing testcase per
+// structural error, so CI can annotate a PR with exactly which subject (or
+// binding) exceeded its permitted scope using tooling that already
+// understands JUnit.
+func walkReportJUnitXML(report WalkReport) ([]byte, error) {
+	suite := junitTestSuite{Name: "rbac-walk"}
+
+	for _, result := range report.Subjects {
+		classname := result.Namespace
+		if classname == "" {
+			classname = "cluster"
+		}
+		tc := junitTestCase{
+			Name:      fmt.Sprintf("%s/%s", result.Subject.Kind, result.Subject.Name),
+			Classname: classname,
+		}
+		if !result.Covers {
+			tc.Failure = &junitFailure{
+				Message: "subject exceeds reference policy",
+				Text:    strings.Join(result.Diagnostics, "\n"),
+			}
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	for _, errMsg := range report.Errors {
+		suite.Tests++
+		suite.Failures++
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      "structural-error",
+			Classname: "walk",
+			Failure:   &junitFailure{Message: errMsg},
+		})
+	}
+
+	marshaled, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), marshaled...), nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/xml"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestRunWalkModeAggregatedClusterRoleBinding(t *testing.T) {
+	dir := t.TempDir()
+	writeTestManifest(t, dir, "tenant-a.yaml", `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: viewer
+  labels:
+    rbac.example.com/aggregate-to-contributor: "true"
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: contributor
+aggregationRule:
+  clusterRoleSelectors:
+  - matchLabels:
+      rbac.example.com/aggregate-to-contributor: "true"
+rules: []
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: alice-binding
+  namespace: tenant-a
+subjects:
+- kind: User
+  name: alice
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: contributor
+`)
+
+	referenceRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+	}
+
+	report, err := runWalkMode(dir, referenceRules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Errors) != 0 {
+		t.Fatalf("expected no structural errors, got %v", report.Errors)
+	}
+	if len(report.Subjects) != 1 {
+		t.Fatalf("expected 1 subject, got %d", len(report.Subjects))
+	}
+
+	result := report.Subjects[0]
+	if result.Namespace != "tenant-a" {
+		t.Errorf("Namespace = %q, want tenant-a", result.Namespace)
+	}
+	if result.Subject.Name != "alice" {
+		t.Errorf("Subject.Name = %q, want alice", result.Subject.Name)
+	}
+	if !result.Covers {
+		t.Errorf("expected alice's aggregated viewer rules to be covered, got uncovered: %+v", result.UncoveredRules)
+	}
+}
+
+func TestRunWalkModeDeniedSubject(t *testing.T) {
+	dir := t.TempDir()
+	writeTestManifest(t, dir, "tenant-b.yaml", `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: excessive
+  namespace: tenant-b
+rules:
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: ["delete"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: bob-binding
+  namespace: tenant-b
+subjects:
+- kind: User
+  name: bob
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: excessive
+`)
+
+	referenceRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+	}
+
+	report, err := runWalkMode(dir, referenceRules)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Subjects) != 1 || report.Subjects[0].Covers {
+		t.Fatalf("expected bob to be denied, got %+v", report.Subjects)
+	}
+	if len(report.Subjects[0].Diagnostics) == 0 {
+		t.Error("expected diagnostics for the denied subject")
+	}
+}
+
+func TestRunWalkModeMissingRoleRef(t *testing.T) {
+	dir := t.TempDir()
+	writeTestManifest(t, dir, "tenant-c.yaml", `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: carol-binding
+  namespace: tenant-c
+subjects:
+- kind: User
+  name: carol
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: does-not-exist
+`)
+
+	report, err := runWalkMode(dir, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Subjects) != 0 {
+		t.Errorf("expected no resolved subjects for a binding with a missing role ref, got %+v", report.Subjects)
+	}
+	if len(report.Errors) != 1 {
+		t.Fatalf("expected 1 structural error, got %v", report.Errors)
+	}
+}
+
+func TestWalkReportJUnitXML(t *testing.T) {
+	report := WalkReport{
+		Subjects: []WalkSubjectResult{
+			{Namespace: "tenant-a", Subject: rbacv1.Subject{Kind: "User", Name: "alice"}, Covers: true},
+			{Namespace: "tenant-b", Subject: rbacv1.Subject{Kind: "User", Name: "bob"}, Covers: false, Diagnostics: []string{"denied"}},
+		},
+		Errors: []string{"RoleBinding tenant-c/carol-binding references missing Role \"does-not-exist\""},
+	}
+
+	xmlBytes, err := walkReportJUnitXML(report)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var suite junitTestSuite
+	if err := xml.Unmarshal(xmlBytes, &suite); err != nil {
+		t.Fatalf("failed to parse generated JUnit XML: %v", err)
+	}
+	if suite.Tests != 3 || suite.Failures != 2 {
+		t.Errorf("Tests/Failures = %d/%d, want 3/2", suite.Tests, suite.Failures)
+	}
+}
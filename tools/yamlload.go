@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadedRoles is the set of Role/ClusterRole/RoleBinding/ClusterRoleBinding
+// objects found in a manifest stream, before the Role/ClusterRole half has
+// been reduced to a flat PolicyRule set. RoleBindings/ClusterRoleBindings
+// are only populated by callers that care about them (walk mode); plain
+// reference/user rule loading ignores them.
+type LoadedRoles struct {
+	Roles               []rbacv1.Role
+	ClusterRoles        []rbacv1.ClusterRole
+	RoleBindings        []rbacv1.RoleBinding
+	ClusterRoleBindings []rbacv1.ClusterRoleBinding
+}
+
+// loadRolesFromFile reads path -- a single Role/ClusterRole manifest, or a
+// multi-document YAML stream / List containing several -- and returns every
+// Role and ClusterRole found in it. This lets CI point the validator
+// straight at the YAML files in components/konflux-rbac/ instead of
+// pre-extracting PolicyRules into JSON.
+func loadRolesFromFile(path string) (LoadedRoles, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path comes from an operator-supplied CLI flag
+	if err != nil {
+		return LoadedRoles{}, err
+	}
+	return loadRolesFromYAML(data)
+}
+
+func loadRolesFromYAML(data []byte) (LoadedRoles, error) {
+	var out LoadedRoles
+	for _, doc := range splitYAMLDocuments(data) {
+		jsonBytes, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return out, fmt.Errorf("converting YAML document to JSON: %w", err)
+		}
+		if err := appendRoleJSON(jsonBytes, &out); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// appendRoleJSON decodes a single JSON document (already converted from
+// YAML) and appends any Role/ClusterRole/RoleBinding/ClusterRoleBinding it
+// describes to out. "List" and any of the four kind-specific List variants
+// are expanded recursively.
+func appendRoleJSON(raw []byte, out *LoadedRoles) error {
+	var probe struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return fmt.Errorf("decoding manifest: %w", err)
+	}
+
+	switch probe.Kind {
+	case "":
+		return nil
+	case "Role":
+		var role rbacv1.Role
+		if err := json.Unmarshal(raw, &role); err != nil {
+			return fmt.Errorf("decoding Role: %w", err)
+		}
+		out.Roles = append(out.Roles, role)
+	case "ClusterRole":
+		var clusterRole rbacv1.ClusterRole
+		if err := json.Unmarshal(raw, &clusterRole); err != nil {
+			return fmt.Errorf("decoding ClusterRole: %w", err)
+		}
+		out.ClusterRoles = append(out.ClusterRoles, clusterRole)
+	case "RoleBinding":
+		var binding rbacv1.RoleBinding
+		if err := json.Unmarshal(raw, &binding); err != nil {
+			return fmt.Errorf("decoding RoleBinding: %w", err)
+		}
+		out.RoleBindings = append(out.RoleBindings, binding)
+	case "ClusterRoleBinding":
+		var binding rbacv1.ClusterRoleBinding
+		if err := json.Unmarshal(raw, &binding); err != nil {
+			return fmt.Errorf("decoding ClusterRoleBinding: %w", err)
+		}
+		out.ClusterRoleBindings = append(out.ClusterRoleBindings, binding)
+	case "List", "RoleList", "ClusterRoleList", "RoleBindingList", "ClusterRoleBindingList":
+		for _, item := range probe.Items {
+			if err := appendRoleJSON(item, out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// splitYAMLDocuments splits a multi-document YAML stream on "---"
+// separator lines.
+func splitYAMLDocuments(data []byte) [][]byte {
+	var docs [][]byte
+	var current bytes.Buffer
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "---" {
+			docs = append(docs, current.Bytes())
+			current = bytes.Buffer{}
+			continue
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+	docs = append(docs, current.Bytes())
+
+	var nonEmpty [][]byte
+	for _, doc := range docs {
+		if len(bytes.TrimSpace(doc)) > 0 {
+			nonEmpty = append(nonEmpty, doc)
+		}
+	}
+	return nonEmpty
+}
+
+// policyRules flattens a LoadedRoles into a single PolicyRule set, resolving
+// each ClusterRole's AggregationRule against the other ClusterRoles loaded
+// alongside it. When the stream contains both an aggregating ClusterRole
+// and the constituent ClusterRoles it aggregates, the constituents'
+// own rules are expanded once via the aggregator and once directly; the
+// result is deduplicated so that overlap doesn't double-count a rule.
+func (l LoadedRoles) policyRules() []rbacv1.PolicyRule {
+	var rules []rbacv1.PolicyRule
+	for _, role := range l.Roles {
+		rules = append(rules, role.Rules...)
+	}
+	for _, clusterRole := range l.ClusterRoles {
+		rules = append(rules, clusterRoleRules(clusterRole, l.ClusterRoles)...)
+	}
+	return dedupePolicyRules(rules)
+}
+
+// dedupePolicyRules removes exact-duplicate PolicyRules, preserving the
+// order of first occurrence.
+func dedupePolicyRules(rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	seen := make(map[string]struct{}, len(rules))
+	out := make([]rbacv1.PolicyRule, 0, len(rules))
+	for _, rule := range rules {
+		k := ruleSortKey(rule)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		out = append(out, rule)
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package main
+
+import "testing"
+
+func TestLoadRolesFromYAMLSingleRole(t *testing.T) {
+	data := []byte(`
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: releaser
+  namespace: tenant-a
+rules:
+- apiGroups: ["appstudio.redhat.com"]
+  resources: ["releases"]
+  verbs: ["get", "list"]
+`)
+
+	loaded, err := loadRolesFromYAML(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded.Roles) != 1 {
+		t.Fatalf("expected 1 Role, got %d", len(loaded.Roles))
+	}
+	if loaded.Roles[0].Name != "releaser" {
+		t.Errorf("expected Role name releaser, got %q", loaded.Roles[0].Name)
+	}
+}
+
+func TestLoadRolesFromYAMLMultiDocWithAggregation(t *testing.T) {
+	data := []byte(`
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: aggregate-view
+aggregationRule:
+  clusterRoleSelectors:
+  - matchLabels:
+      rbac.example.com/aggregate-to-view: "true"
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: pods-view
+  labels:
+    rbac.example.com/aggregate-to-view: "true"
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+`)
+
+	loaded, err := loadRolesFromYAML(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded.ClusterRoles) != 2 {
+		t.Fatalf("expected 2 ClusterRoles, got %d", len(loaded.ClusterRoles))
+	}
+
+	rules := loaded.policyRules()
+	if len(rules) != 1 || rules[0].Resources[0] != "pods" {
+		t.Errorf("expected aggregation to pull in pods-view's rules, got %+v", rules)
+	}
+}
+
+func TestLoadRolesFromYAMLList(t *testing.T) {
+	data := []byte(`
+apiVersion: v1
+kind: List
+items:
+- apiVersion: rbac.authorization.k8s.io/v1
+  kind: Role
+  metadata:
+    name: a
+    namespace: ns
+  rules:
+  - apiGroups: [""]
+    resources: ["configmaps"]
+    verbs: ["get"]
+- apiVersion: rbac.authorization.k8s.io/v1
+  kind: ClusterRole
+  metadata:
+    name: b
+  rules:
+  - apiGroups: [""]
+    resources: ["pods"]
+    verbs: ["get"]
+`)
+
+	loaded, err := loadRolesFromYAML(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded.Roles) != 1 || len(loaded.ClusterRoles) != 1 {
+		t.Fatalf("expected 1 Role and 1 ClusterRole from the List, got %d roles, %d clusterRoles", len(loaded.Roles), len(loaded.ClusterRoles))
+	}
+}